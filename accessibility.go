@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+// control lays out w as a button that screen readers announce with
+// description, for clickable areas that are not material buttons such as
+// avatars and list rows. Like the other buttons, it takes the keyboard focus
+// with Tab and is clicked with Return or Space.
+func control(gtx C, click *widget.Clickable, description string, w layout.Widget) D {
+	return material.Clickable(gtx, click, func(gtx C) D {
+		semantic.DescriptionOp(description).Add(gtx.Ops)
+		return w(gtx)
+	})
+}
+
+// contactDescription describes a row of the contact list, with the state that
+// its badges show
+func contactDescription(c *catshadow.Client, contact *catshadow.Contact) string {
+	nickname := contact.Nickname
+	states := []string{nickname}
+	if contact.IsPending {
+		states = append(states, "key exchange pending")
+	}
+	if isVerified(c, nickname) {
+		states = append(states, "verified")
+	}
+	if hasFlag(c, pinnedFlag, nickname) {
+		states = append(states, "pinned")
+	}
+	if hasFlag(c, favoriteFlag, nickname) {
+		states = append(states, "favorite")
+	}
+	if isBlocked(c, nickname) {
+		states = append(states, "blocked")
+	}
+	if isMuted(c, nickname) {
+		states = append(states, "muted")
+	} else if isUnread(c, contact) {
+		states = append(states, "unread messages")
+	}
+	return strings.Join(states, ", ")
+}
+
+// messageDescription describes a message of the conversation with nickname,
+// with the delivery status that its icon shows
+func messageDescription(msg *catshadow.Message, nickname string) string {
+	if !msg.Outbound {
+		return "Message from " + nickname
+	}
+	switch {
+	case msg.Delivered:
+		return "Your message, delivered"
+	case msg.Sent:
+		return "Your message, sent"
+	default:
+		return "Your message, queued"
+	}
+}
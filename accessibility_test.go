@@ -0,0 +1,154 @@
+package main
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gioui.org/io/router"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/client"
+	"github.com/katzenpost/katzenpost/client/config"
+	"github.com/katzenpost/katzenpost/core/log"
+)
+
+// testContact is the nickname of the contact of the offline test client
+const testContact = "alice"
+
+// newOfflineClient starts a catshadow client with a new statefile that never
+// connects, holding one contact whose key exchange is pending
+func newOfflineClient(t *testing.T) *catshadow.Client {
+	cfg, err := config.Load(cfgWithoutTor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Logging.Disable = true
+	backendLog, err := log.New("", "ERROR", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixnet, err := client.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statefile := filepath.Join(t.TempDir(), "statefile")
+	stateWorker, err := catshadow.NewStateWriter(backendLog.GetLogger("catshadow_state"), statefile, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateWorker.Start()
+	c, err := catshadow.New(backendLog, mixnet, stateWorker, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	t.Cleanup(c.Shutdown)
+	c.NewContact(testContact, []byte("a shared secret"))
+	// the worker adds the contact before it answers
+	if _, ok := c.GetContacts()[testContact]; !ok {
+		t.Fatalf("%s was not added", testContact)
+	}
+	return c
+}
+
+// accessibleName returns the name a screen reader announces for n: its
+// description, its label, or else the first label of its contents
+func accessibleName(n router.SemanticNode) string {
+	if n.Desc.Description != "" {
+		return n.Desc.Description
+	}
+	if n.Desc.Label != "" {
+		return n.Desc.Label
+	}
+	for _, child := range n.Children {
+		if name := accessibleName(child); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkNames reports every clickable node under n that has no name
+func checkNames(t *testing.T, page string, n router.SemanticNode) {
+	t.Helper()
+	clickable := n.Desc.Gestures&router.ClickGesture != 0
+	if clickable && n.Desc.Class != semantic.Editor && accessibleName(n) == "" {
+		t.Errorf("%s: %v at %v has no description", page, n.Desc.Class, n.Desc.Bounds)
+	}
+	for _, child := range n.Children {
+		checkNames(t, page, child)
+	}
+}
+
+func TestClickablesHaveDescriptions(t *testing.T) {
+	useDataDir(t)
+	a := newApp(nil)
+	a.c = newOfflineClient(t)
+	result := make(chan interface{})
+
+	pages := map[string]func() Page{
+		"sign in":      func() Page { return newSignInPage(a) },
+		"profiles":     func() Page { return newProfilesPage(a) },
+		"restore":      func() Page { return newRestorePage(a) },
+		"wipe":         func() Page { return newWipePage(a) },
+		"unlock":       func() Page { return newUnlockPage(result) },
+		"connecting":   func() Page { return newConnectingPage(result) },
+		"home":         func() Page { return newHomePage(a) },
+		"add contact":  func() Page { return newAddContactPage(a) },
+		"conversation": func() Page { return newConversationPage(a, testContact) },
+		"edit contact": func() Page { return newEditContactPage(a, testContact) },
+		"notes":        func() Page { return newContactNotesPage(a, testContact) },
+		"history":      func() Page { return newExportHistoryPage(a, testContact) },
+		"rename":       func() Page { return newRenameContactPage(a, testContact) },
+		"verify":       func() Page { return newVerifyContactPage(a, testContact) },
+		"pending":      func() Page { return newPendingPage(a) },
+		"secret":       func() Page { return newSecretPage(a, testContact) },
+		"keys":         func() Page { return newKeysPage(a) },
+		"settings":     func() Page { return newSettingsPage(a) },
+		"passphrase":   func() Page { return newChangePassphrasePage(a) },
+		"duress":       func() Page { return newDuressPage(a) },
+		"backup":       func() Page { return newBackupPage(a) },
+		"address book": func() Page { return newAddressBookPage(a) },
+		"spool":        func() Page { return newSpoolPage(a) },
+	}
+	// the home page shows the conversation beside the contacts when wide
+	sizes := []image.Point{{X: 400, Y: 800}, {X: 1400, Y: 900}}
+
+	for name, newPage := range pages {
+		for _, size := range sizes {
+			var r router.Router
+			gtx := layout.Context{
+				Ops:         new(op.Ops),
+				Constraints: layout.Exact(size),
+				Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+				Queue:       &r,
+				Now:         time.Now(),
+			}
+			newPage().Layout(gtx)
+			r.Frame(gtx.Ops)
+			checkNames(t, name, r.AppendSemantics(nil)[0])
+		}
+	}
+}
+
+func TestMessageDescription(t *testing.T) {
+	tests := []struct {
+		msg  catshadow.Message
+		want string
+	}{
+		{catshadow.Message{}, "Message from " + testContact},
+		{catshadow.Message{Outbound: true}, "Your message, queued"},
+		{catshadow.Message{Outbound: true, Sent: true}, "Your message, sent"},
+		{catshadow.Message{Outbound: true, Sent: true, Delivered: true}, "Your message, delivered"},
+	}
+	for _, test := range tests {
+		if got := messageDescription(&test.msg, testContact); got != test.want {
+			t.Errorf("got %q for %+v, want %q", got, test.msg, test.want)
+		}
+	}
+}
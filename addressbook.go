@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/core/crypto/rand"
+	"github.com/katzenpost/katzenpost/core/utils"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	addressBookFile = "addressbook.katzen"
+	addressBookSalt = 16
+)
+
+var (
+	addressBookMagic = []byte("KATZENAB1")
+
+	errAddressBookFormat     = errors.New("Not a katzen address book")
+	errAddressBookPassphrase = errors.New("Wrong passphrase or damaged address book")
+)
+
+// addressBookEntry holds the details of a contact that can be moved between
+// devices. It never contains key material, so the contacts must be added
+// again with a new key exchange.
+type addressBookEntry struct {
+	Nickname   string
+	Avatar     []byte
+	Expiration time.Duration
+	Notes      string
+	Fields     []contactField
+	Flags      []string
+}
+
+// transferableFlags are the contact flags carried by the address book
+var transferableFlags = []string{pinnedFlag, archivedFlag, favoriteFlag, blockedFlag}
+
+// passphraseKey derives the key of a file encrypted with a passphrase, such as
+// an address book or a backup
+func passphraseKey(passphrase, salt []byte) *[32]byte {
+	var key [32]byte
+	k := argon2.Key(passphrase, salt, 3, 32*1024, 4, 32)
+	copy(key[:], k)
+	utils.ExplicitBzero(k)
+	return &key
+}
+
+// getAddressBook returns the address book entries of all contacts
+func getAddressBook(c *catshadow.Client) []*addressBookEntry {
+	entries := make([]*addressBookEntry, 0)
+	for nickname := range c.GetContacts() {
+		e := &addressBookEntry{Nickname: nickname,
+			Notes:  getNotes(c, nickname),
+			Fields: getFields(c, nickname),
+		}
+		if b, err := c.GetBlob("avatar://" + nickname); err == nil {
+			e.Avatar = b
+		}
+		if d, err := c.GetExpiration(nickname); err == nil {
+			e.Expiration = d
+		}
+		for _, flag := range transferableFlags {
+			if hasFlag(c, flag, nickname) {
+				e.Flags = append(e.Flags, flag)
+			}
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Nickname < entries[j].Nickname
+	})
+	return entries
+}
+
+// exportAddressBook writes the address book encrypted with passphrase to path
+func exportAddressBook(c *catshadow.Client, path string, passphrase []byte) (int, error) {
+	entries := getAddressBook(c)
+	payload, err := cbor.Marshal(entries)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.ExplicitBzero(payload)
+
+	salt := make([]byte, addressBookSalt)
+	if _, err := rand.Reader.Read(salt); err != nil {
+		return 0, err
+	}
+	var nonce [24]byte
+	if _, err := rand.Reader.Read(nonce[:]); err != nil {
+		return 0, err
+	}
+	key := passphraseKey(passphrase, salt)
+	defer utils.ExplicitBzero(key[:])
+
+	out := append([]byte{}, addressBookMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce[:]...)
+	out = secretbox.Seal(out, payload, &nonce, key)
+	return len(entries), os.WriteFile(path, out, 0600)
+}
+
+// readAddressBook decrypts the address book at path
+func readAddressBook(path string, passphrase []byte) ([]*addressBookEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(b, addressBookMagic) || len(b) < len(addressBookMagic)+addressBookSalt+24+secretbox.Overhead {
+		return nil, errAddressBookFormat
+	}
+	b = b[len(addressBookMagic):]
+	salt, b := b[:addressBookSalt], b[addressBookSalt:]
+	var nonce [24]byte
+	copy(nonce[:], b[:24])
+	key := passphraseKey(passphrase, salt)
+	defer utils.ExplicitBzero(key[:])
+	payload, ok := secretbox.Open(nil, b[24:], &nonce, key)
+	if !ok {
+		return nil, errAddressBookPassphrase
+	}
+	defer utils.ExplicitBzero(payload)
+	entries := make([]*addressBookEntry, 0)
+	if err := cbor.Unmarshal(payload, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// importAddressBook stores the entries of an address book until the contacts
+// are added again. Details of existing contacts are applied immediately.
+func importAddressBook(c *catshadow.Client, entries []*addressBookEntry) (int, error) {
+	contacts := c.GetContacts()
+	for _, e := range entries {
+		if _, ok := contacts[e.Nickname]; ok {
+			applyImport(c, e)
+			continue
+		}
+		b, err := cbor.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		c.AddBlob("import://"+e.Nickname, b)
+	}
+	return len(entries), nil
+}
+
+// getImport returns the imported details for a nickname, if any
+func getImport(c *catshadow.Client, nickname string) (*addressBookEntry, bool) {
+	b, err := c.GetBlob("import://" + nickname)
+	if err != nil {
+		return nil, false
+	}
+	e := &addressBookEntry{}
+	if err := cbor.Unmarshal(b, e); err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+// applyImport restores the imported details of a contact. The expiration can
+// only be changed once catshadow has created the contact, so the entry is
+// kept until it has been applied.
+func applyImport(c *catshadow.Client, e *addressBookEntry) {
+	if len(e.Avatar) > 0 {
+		c.AddBlob("avatar://"+e.Nickname, e.Avatar)
+		delete(avatars, e.Nickname)
+	}
+	if e.Notes != "" || len(e.Fields) > 0 {
+		setNotes(c, e.Nickname, e.Notes, e.Fields)
+	}
+	for _, flag := range e.Flags {
+		setFlag(c, flag, e.Nickname, true)
+	}
+	if err := c.ChangeExpiration(e.Nickname, e.Expiration); err == nil {
+		c.DeleteBlob("import://" + e.Nickname)
+	}
+}
+
+// applyPendingImport applies the remaining imported details of a contact, if any
+func applyPendingImport(c *catshadow.Client, nickname string) {
+	if e, ok := getImport(c, nickname); ok {
+		applyImport(c, e)
+	}
+}
+
+// AddressBookPage exports and imports the contact details
+type AddressBookPage struct {
+	a          *App
+	back       *widget.Clickable
+	export     *widget.Clickable
+	importBook *widget.Clickable
+	path       *widget.Editor
+	passphrase *widget.Editor
+	status     string
+}
+
+// ShowAddressBookClick is the event that requests the address book page
+type ShowAddressBookClick struct{}
+
+// Layout returns the address book file and passphrase fields
+func (p *AddressBookPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Address Book").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(th, "Nicknames, avatars, message deletion, notes and details of your contacts are saved, but no keys. Contacts must be added again with a new key exchange, and their details are restored when the nickname matches.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.path, "File").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.passphrase, "Passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(func(gtx C) D {
+							return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceEvenly}.Layout(gtx,
+								layout.Rigid(material.Button(th, p.export, "Export").Layout),
+								layout.Rigid(material.Button(th, p.importBook, "Import").Layout),
+							)
+						}),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+				})
+			}),
+		)
+	})
+}
+
+// Event handles the export and import buttons
+func (p *AddressBookPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.export.Clicked() {
+		if len(p.passphrase.Text()) < minPasswordLen {
+			p.status = fmt.Sprintf("The passphrase must be at least %d characters", minPasswordLen)
+			p.passphrase.Focus()
+			return nil
+		}
+		passphrase := []byte(p.passphrase.Text())
+		n, err := exportAddressBook(p.a.c, p.path.Text(), passphrase)
+		utils.ExplicitBzero(passphrase)
+		if err != nil {
+			p.status = err.Error()
+		} else {
+			p.status = fmt.Sprintf("Exported %d contacts", n)
+			p.passphrase.SetText("")
+		}
+		return RedrawEvent{}
+	}
+	if p.importBook.Clicked() {
+		passphrase := []byte(p.passphrase.Text())
+		entries, err := readAddressBook(p.path.Text(), passphrase)
+		utils.ExplicitBzero(passphrase)
+		if err == nil {
+			var n int
+			n, err = importAddressBook(p.a.c, entries)
+			p.status = fmt.Sprintf("Imported %d contacts", n)
+			p.passphrase.SetText("")
+		}
+		if err != nil {
+			p.status = err.Error()
+		}
+		return RedrawEvent{}
+	}
+	return nil
+}
+
+func (p *AddressBookPage) Start(stop <-chan struct{}) {
+}
+
+func newAddressBookPage(a *App) *AddressBookPage {
+	p := &AddressBookPage{a: a,
+		back:       &widget.Clickable{},
+		export:     &widget.Clickable{},
+		importBook: &widget.Clickable{},
+		path:       &widget.Editor{SingleLine: true},
+		passphrase: &widget.Editor{SingleLine: true, Mask: '*'},
+	}
+	if dir, err := appDataDir(); err == nil {
+		p.path.SetText(filepath.Join(dir, addressBookFile))
+	}
+	return p
+}
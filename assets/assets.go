@@ -1,5 +1,15 @@
 package assets
 
+import (
+	_ "embed"
+)
+
+// Wordlist is the newline separated list of words used to generate
+// secrets that can be read aloud
+//
+//go:embed wordlist.txt
+var Wordlist string
+
 var Logob64 string = `
 iVBORw0KGgoAAAANSUhEUgAAADAAAAAwCAYAAABXAvmHAAAQjXpUWHRSYXcgcHJvZmlsZSB0eXBl
 IGV4aWYAAHjarVrZdeU6jPxHFBMC9yUcrudMBhP+FEBKlu7mpZ9v21LLMkUChUIBFI3/+99J/4Ov
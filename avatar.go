@@ -3,9 +3,7 @@ package main
 import (
 	"bytes"
 	"gioui.org/app"
-	"gioui.org/gesture"
 	"gioui.org/layout"
-	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
 	"gioui.org/widget"
@@ -29,13 +27,13 @@ var (
 
 type AvatarPicker struct {
 	a        *App
-	avatar   *gesture.Click
+	avatar   *widget.Clickable
 	nickname string
 	path     string
 	back     *widget.Clickable
 	clear    *widget.Clickable
 	up       *widget.Clickable
-	clicks   map[string]*gesture.Click
+	clicks   map[string]*widget.Clickable
 	thumbs   map[os.FileInfo]*image.Image
 	files    []os.FileInfo
 	thsz     int
@@ -55,7 +53,7 @@ func (p *AvatarPicker) Layout(gtx layout.Context) layout.Dimensions {
 			// back to Edit Contact
 			layout.Rigid(func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Baseline}.Layout(gtx,
-					layout.Rigid(button(th, p.back, backIcon).Layout),
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					layout.Rigid(material.H6(th, "Choose Avatar").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout),
@@ -63,14 +61,11 @@ func (p *AvatarPicker) Layout(gtx layout.Context) layout.Dimensions {
 			}),
 			// avatar icon
 			layout.Rigid(func(gtx C) D {
-				dims := layout.Center.Layout(gtx, func(gtx C) D {
-					return layoutAvatar(gtx, p.a.c, p.nickname)
+				return layout.Center.Layout(gtx, func(gtx C) D {
+					return control(gtx, p.avatar, "Generate a new avatar", func(gtx C) D {
+						return layoutAvatar(gtx, p.a.c, p.nickname)
+					})
 				})
-				a := clip.Rect(image.Rectangle{Max: dims.Size})
-				t := a.Push(gtx.Ops)
-				p.avatar.Add(gtx.Ops)
-				t.Pop()
-				return dims
 			}),
 			// cwd and buttons
 			layout.Rigid(func(gtx C) D {
@@ -150,18 +145,15 @@ func (p *AvatarPicker) Layout(gtx layout.Context) layout.Dimensions {
 					if fn.IsDir() {
 						// is a directory, attach clickable that will update the path if clicked...
 						if _, ok := p.clicks[fn.Name()]; !ok {
-							c := new(gesture.Click)
+							c := new(widget.Clickable)
 							p.clicks[fn.Name()] = c
 						}
 						in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
-						dims := in.Layout(gtx, func(gtx C) D {
-							return material.Body1(th, fn.Name()).Layout(gtx)
+						return control(gtx, p.clicks[fn.Name()], "Open folder "+fn.Name(), func(gtx C) D {
+							return in.Layout(gtx, func(gtx C) D {
+								return material.Body1(th, fn.Name()).Layout(gtx)
+							})
 						})
-						a := clip.Rect(image.Rectangle{Max: dims.Size})
-						t := a.Push(gtx.Ops)
-						p.clicks[fn.Name()].Add(gtx.Ops)
-						t.Pop()
-						return dims
 					} else {
 						p.tl.Lock()
 						resized, ok := p.thumbs[fn]
@@ -170,25 +162,21 @@ func (p *AvatarPicker) Layout(gtx layout.Context) layout.Dimensions {
 							// skip element
 							return layout.Dimensions{Size: image.Point{X: sz, Y: sz}}
 						}
-						t := func(ctx C) D {
+						t := func(gtx C) D {
 							sc := float32(sz) / float32(gtx.Dp(unit.Dp(float32(sz))))
 							th := widget.Image{Scale: sc, Src: paint.NewImageOp(*resized)}
-							// render thumb and attach the click handlers
+							// render thumb
 							in := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4)}
-							dims := in.Layout(gtx, func(gtx C) D {
+							return in.Layout(gtx, func(gtx C) D {
 								return th.Layout(gtx)
 							})
-							a := clip.Rect(image.Rectangle{Max: dims.Size})
-							t := a.Push(gtx.Ops)
-							if _, ok := p.clicks[fn.Name()]; !ok {
-								c := new(gesture.Click)
-								p.clicks[fn.Name()] = c
-							}
-							p.clicks[fn.Name()].Add(gtx.Ops)
-							t.Pop()
-							return dims
 						}
-						return t(gtx)
+						// attach the click handler
+						if _, ok := p.clicks[fn.Name()]; !ok {
+							c := new(widget.Clickable)
+							p.clicks[fn.Name()] = c
+						}
+						return control(gtx, p.clicks[fn.Name()], "Use image "+fn.Name()+" as avatar", t)
 					}
 					return layout.Dimensions{}
 				})
@@ -207,34 +195,30 @@ func (p *AvatarPicker) Event(gtx C) interface{} {
 		return BackEvent{}
 	}
 
-	for _, e := range p.avatar.Events(gtx.Queue) {
-		if e.Type == gesture.TypeClick {
-			ct := Contactal{}
-			ct.Reset()
-			sz := image.Point{X: gtx.Dp(96), Y: gtx.Dp(96)}
-			i := ct.Render(sz)
-			b := new(bytes.Buffer)
-			if err := png.Encode(b, i); err == nil {
-				p.a.c.AddBlob("avatar://"+p.nickname, b.Bytes())
-				delete(avatars, p.nickname)
-				return RedrawEvent{}
-			}
+	if p.avatar.Clicked() {
+		ct := Contactal{}
+		ct.Reset()
+		sz := image.Point{X: gtx.Dp(96), Y: gtx.Dp(96)}
+		i := ct.Render(sz)
+		b := new(bytes.Buffer)
+		if err := png.Encode(b, i); err == nil {
+			p.a.c.AddBlob("avatar://"+p.nickname, b.Bytes())
+			delete(avatars, p.nickname)
+			return RedrawEvent{}
 		}
 	}
 
 	for filename, click := range p.clicks {
-		for _, e := range click.Events(gtx.Queue) {
-			if e.Type == gesture.TypeClick {
-				// if it is a directory path - change the path
-				// if it is a file path, return the file selection event
-				if u, err := filepath.Abs(filepath.Join(p.path, filename)); err == nil {
-					if f, err := os.Stat(u); err == nil {
-						if f.IsDir() {
-							return ChooseAvatarPath{nickname: p.nickname, path: u}
-							p.path = u
-						} else {
-							p.a.setAvatar(p.nickname, u)
-						}
+		if click.Clicked() {
+			// if it is a directory path - change the path
+			// if it is a file path, return the file selection event
+			if u, err := filepath.Abs(filepath.Join(p.path, filename)); err == nil {
+				if f, err := os.Stat(u); err == nil {
+					if f.IsDir() {
+						return ChooseAvatarPath{nickname: p.nickname, path: u}
+						p.path = u
+					} else {
+						p.a.setAvatar(p.nickname, u)
 					}
 				}
 			}
@@ -323,11 +307,11 @@ func newAvatarPicker(a *App, nickname string, path string) *AvatarPicker {
 
 	ap := &AvatarPicker{up: &widget.Clickable{},
 		a:        a,
-		avatar:   &gesture.Click{},
+		avatar:   &widget.Clickable{},
 		nickname: nickname,
 		back:     &widget.Clickable{},
 		clear:    &widget.Clickable{},
-		clicks:   make(map[string]*gesture.Click),
+		clicks:   make(map[string]*widget.Clickable),
 		thumbs:   make(map[os.FileInfo]*image.Image),
 		files:    make([]os.FileInfo, 0),
 		tl:       new(sync.Mutex),
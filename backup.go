@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/core/crypto/rand"
+	"github.com/katzenpost/katzenpost/core/utils"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	backupFileFormat = "katzen-backup-2006-01-02.katzen"
+	backupTimeFormat = "2 Jan 2006 15:04"
+)
+
+var (
+	backupMagic = []byte("KATZENBK1")
+
+	errBackupFormat     = errors.New("Not a katzen backup")
+	errBackupPassphrase = errors.New("Wrong backup passphrase or damaged backup")
+	errBackupNeedsPass  = errors.New("This backup is protected by a backup passphrase")
+)
+
+// backupFile is a snapshot of the statefile. State is the statefile as
+// written by catshadow, and is encrypted again with a backup passphrase when
+// Salt is set.
+type backupFile struct {
+	Saved time.Time
+	Salt  []byte
+	Nonce []byte
+	State []byte
+}
+
+// encrypted returns true if the backup is protected by a backup passphrase
+func (b *backupFile) encrypted() bool {
+	return len(b.Salt) > 0
+}
+
+// writeBackup copies statefile to path, encrypted with passphrase if it is
+// not empty. The client must be shut down first so that the statefile does
+// not change while it is copied.
+func writeBackup(statefile, path string, passphrase []byte) error {
+	fi, err := os.Stat(statefile)
+	if err != nil {
+		return err
+	}
+	state, err := os.ReadFile(statefile)
+	if err != nil {
+		return err
+	}
+	b := &backupFile{Saved: fi.ModTime(), State: state}
+	if len(passphrase) > 0 {
+		b.Salt = make([]byte, addressBookSalt)
+		if _, err := rand.Reader.Read(b.Salt); err != nil {
+			return err
+		}
+		var nonce [24]byte
+		if _, err := rand.Reader.Read(nonce[:]); err != nil {
+			return err
+		}
+		key := passphraseKey(passphrase, b.Salt)
+		defer utils.ExplicitBzero(key[:])
+		b.Nonce = nonce[:]
+		b.State = secretbox.Seal(nil, state, &nonce, key)
+	}
+	payload, err := cbor.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(append([]byte{}, backupMagic...), payload...), 0600)
+}
+
+// readBackup reads the backup at path, removing the backup passphrase
+// encryption if there is any
+func readBackup(path string, passphrase []byte) (*backupFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(raw, backupMagic) {
+		return nil, errBackupFormat
+	}
+	b := &backupFile{}
+	if err := cbor.Unmarshal(raw[len(backupMagic):], b); err != nil {
+		return nil, errBackupFormat
+	}
+	if !b.encrypted() {
+		return b, nil
+	}
+	if len(passphrase) == 0 {
+		return nil, errBackupNeedsPass
+	}
+	if len(b.Nonce) != 24 {
+		return nil, errBackupFormat
+	}
+	var nonce [24]byte
+	copy(nonce[:], b.Nonce)
+	key := passphraseKey(passphrase, b.Salt)
+	defer utils.ExplicitBzero(key[:])
+	state, ok := secretbox.Open(nil, b.State, &nonce, key)
+	if !ok {
+		return nil, errBackupPassphrase
+	}
+	b.State, b.Salt, b.Nonce = state, nil, nil
+	return b, nil
+}
+
+// restoreBackup replaces statefile with the backup. The replaced statefile
+// is kept alongside it until the restored statefile is unlocked, so that a
+// restore with a forgotten passphrase can be undone by hand.
+func restoreBackup(statefile string, b *backupFile) error {
+	tmp := statefile + ".tmp"
+	if err := writeFileSync(tmp, b.State); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(statefile, statefile+".replaced"); err != nil && !os.IsNotExist(err) {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, statefile); err != nil {
+		return err
+	}
+	return syncDir(statefile)
+}
+
+// shredReplacedStatefile shreds the statefile replaced by a restored backup,
+// once the restored statefile has been unlocked. Unlocking the decoy with the
+// duress passphrase does not show that the restored statefile can be opened.
+func shredReplacedStatefile() {
+	if statefile, err := statefilePath(); err == nil && activeStatefile == statefile {
+		shredFile(statefile + ".replaced")
+	}
+}
+
+// BackupPage writes a backup of the statefile
+type BackupPage struct {
+	a          *App
+	back       *widget.Clickable
+	backup     *widget.Clickable
+	path       *widget.Editor
+	passphrase *widget.Editor
+	status     string
+}
+
+// ShowBackupClick is the event that requests the backup page
+type ShowBackupClick struct{}
+
+// Layout returns the backup file and passphrase fields
+func (p *BackupPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Backup").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(th, "The backup holds your keys, contacts and messages. Katzen signs out while it is written. Without a backup passphrase, the backup is protected by your current passphrase.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, "Keep backups somewhere safe and delete those you no longer need: anyone who can open a backup can read the messages it contains, even after they expire here.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.path, "File").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.passphrase, "Backup passphrase (optional)").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Button(th, p.backup, "Sign out and Back up").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+				})
+			}),
+		)
+	})
+}
+
+// Event handles the backup button
+func (p *BackupPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.backup.Clicked() {
+		if n := len(p.passphrase.Text()); n > 0 && n < minPasswordLen {
+			p.status = fmt.Sprintf("The backup passphrase must be at least %d characters", minPasswordLen)
+			p.passphrase.Focus()
+			return RedrawEvent{}
+		}
+		// halting the client waits for the StateWriter to finish writing
+		shutdownClient(p.a.c)
+		passphrase := []byte(p.passphrase.Text())
+		err := writeBackup(activeStatefile, p.path.Text(), passphrase)
+		utils.ExplicitBzero(passphrase)
+		if err != nil {
+			return restartClient{status: "Backup failed: " + err.Error()}
+		}
+		return restartClient{status: "Backup saved to " + p.path.Text()}
+	}
+	return nil
+}
+
+func (p *BackupPage) Start(stop <-chan struct{}) {
+}
+
+func newBackupPage(a *App) *BackupPage {
+	p := &BackupPage{a: a,
+		back:       &widget.Clickable{},
+		backup:     &widget.Clickable{},
+		path:       &widget.Editor{SingleLine: true},
+		passphrase: &widget.Editor{SingleLine: true, Mask: '*'},
+	}
+	if dir, err := appDataDir(); err == nil {
+		p.path.SetText(filepath.Join(dir, time.Now().Format(backupFileFormat)))
+	}
+	return p
+}
+
+// RestorePage replaces the statefile with a backup. It is reached from the
+// sign in page, so no client is writing the statefile.
+type RestorePage struct {
+	a          *App
+	back       *widget.Clickable
+	restore    *widget.Clickable
+	path       *widget.Editor
+	passphrase *widget.Editor
+	confirm    *widget.Bool
+	warning    string
+	status     string
+}
+
+// ShowRestoreClick is the event that requests the restore page
+type ShowRestoreClick struct{}
+
+// Layout returns the backup file and passphrase fields, and the warning that
+// must be confirmed before a statefile is replaced
+func (p *RestorePage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Restore from Backup").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					children := []layout.FlexChild{
+						layout.Rigid(material.Body2(th, "After restoring, sign in with the passphrase that was in use when the backup was made.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.path, "File").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.passphrase, "Backup passphrase, if any").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+					}
+					if p.warning != "" {
+						children = append(children,
+							layout.Rigid(material.Body2(th, p.warning).Layout),
+							layout.Rigid(material.CheckBox(th, p.confirm, "Replace the current statefile").Layout),
+							layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						)
+					}
+					children = append(children,
+						layout.Rigid(material.Button(th, p.restore, "Restore").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+				})
+			}),
+		)
+	})
+}
+
+// restoreWarning describes what is lost by replacing the current statefile,
+// saved at current, with a backup saved at backup
+func restoreWarning(current, backup time.Time) string {
+	warning := fmt.Sprintf("This replaces your current statefile, saved %s, with the backup saved %s. ",
+		current.Format(backupTimeFormat), backup.Format(backupTimeFormat))
+	if current.After(backup) {
+		warning += "The backup is OLDER than your current state. Restoring rolls back the ratchet keys of every conversation: " +
+			"messages exchanged since the backup was made are lost and cannot be decrypted, " +
+			"contacts added since are lost, and conversations may need a new key exchange. "
+	}
+	warning += "Restoring also brings back keys that katzen had already erased, " +
+		"undoing the forward secrecy of the messages they protected."
+	return warning
+}
+
+// Event handles the restore button
+func (p *RestorePage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	for _, ev := range p.path.Events() {
+		if _, ok := ev.(widget.ChangeEvent); ok {
+			// a confirmation only applies to the backup it warned about
+			p.warning = ""
+			p.confirm.Value = false
+		}
+	}
+	if p.restore.Clicked() {
+		passphrase := []byte(p.passphrase.Text())
+		b, err := readBackup(p.path.Text(), passphrase)
+		utils.ExplicitBzero(passphrase)
+		if err != nil {
+			p.status = err.Error()
+			return RedrawEvent{}
+		}
+		defer utils.ExplicitBzero(b.State)
+		statefile, err := statefilePath()
+		if err != nil {
+			p.status = err.Error()
+			return RedrawEvent{}
+		}
+		if fi, err := os.Stat(statefile); err == nil && !p.confirm.Value {
+			// the user must confirm the warning before a statefile is replaced
+			p.warning = restoreWarning(fi.ModTime(), b.Saved)
+			p.status = ""
+			return RedrawEvent{}
+		}
+		if err := restoreBackup(statefile, b); err != nil {
+			p.status = err.Error()
+			return RedrawEvent{}
+		}
+		return restartClient{status: "Backup restored, enter its passphrase. The replaced statefile is kept as " +
+			statefile + ".replaced until then."}
+	}
+	return nil
+}
+
+func (p *RestorePage) Start(stop <-chan struct{}) {
+}
+
+func newRestorePage(a *App) *RestorePage {
+	return &RestorePage{a: a,
+		back:       &widget.Clickable{},
+		restore:    &widget.Clickable{},
+		path:       &widget.Editor{SingleLine: true},
+		passphrase: &widget.Editor{SingleLine: true, Mask: '*'},
+		confirm:    &widget.Bool{},
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"time"
+
+	"gioui.org/io/clipboard"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+const (
+	defaultClipboardTimeout = 60 * time.Second
+	// maxClipboardTimeout is the longest timeout, in seconds, that can be chosen
+	maxClipboardTimeout = 300.0
+)
+
+// sensitiveClipboard tracks secrets and messages katzen copied to the
+// clipboard, so that they can be cleared after a timeout
+type sensitiveClipboard struct {
+	text    string
+	until   time.Time
+	reading bool
+	clear   *widget.Clickable
+}
+
+var katzenClipboard = &sensitiveClipboard{clear: &widget.Clickable{}}
+
+// clipboardTimeout returns how long copied data stays on the clipboard, or 0
+// if it is never cleared
+func clipboardTimeout(c *catshadow.Client) time.Duration {
+	if c != nil {
+		if b, err := c.GetBlob("ClipboardTimeout"); err == nil {
+			if s, err := strconv.Atoi(string(b)); err == nil {
+				return time.Duration(s) * time.Second
+			}
+		}
+	}
+	return defaultClipboardTimeout
+}
+
+// copySensitive writes text to the clipboard, to be cleared after the timeout
+func (a *App) copySensitive(gtx layout.Context, text string) {
+	clipboard.WriteOp{Text: text}.Add(gtx.Ops)
+	katzenClipboard.text = text
+	katzenClipboard.reading = false
+	katzenClipboard.until = time.Time{}
+	if timeout := clipboardTimeout(a.c); timeout > 0 {
+		katzenClipboard.until = time.Now().Add(timeout)
+	}
+}
+
+// expire clears the copied data as soon as possible, such as when locking
+func (s *sensitiveClipboard) expire() {
+	if s.text != "" {
+		s.until = time.Now()
+	}
+}
+
+// layoutClipboard clears copied data once it expires, if the clipboard still
+// holds it, and shows an indicator while it may be on the clipboard. Clicking
+// the indicator clears it immediately.
+func (a *App) layoutClipboard(gtx layout.Context) {
+	s := katzenClipboard
+	for _, e := range gtx.Events(s) {
+		if e, ok := e.(clipboard.Event); ok && s.reading {
+			if e.Text == s.text {
+				clipboard.WriteOp{}.Add(gtx.Ops)
+			}
+			s.text = ""
+			s.reading = false
+		}
+	}
+	if s.clear.Clicked() {
+		s.expire()
+	}
+	if s.text == "" {
+		return
+	}
+	if !s.until.IsZero() {
+		if !gtx.Now.Before(s.until) {
+			if !s.reading {
+				// the clipboard may have been overwritten since, so read it first
+				clipboard.ReadOp{Tag: s}.Add(gtx.Ops)
+				s.reading = true
+			}
+		} else {
+			op.InvalidateOp{At: s.until}.Add(gtx.Ops)
+		}
+	}
+
+	label := "Clipboard holds Katzen data"
+	if !s.until.IsZero() && gtx.Now.Before(s.until) {
+		label = fmt.Sprintf("Clipboard clears in %s", s.until.Sub(gtx.Now).Round(time.Second))
+		op.InvalidateOp{At: gtx.Now.Add(time.Second)}.Add(gtx.Ops)
+	}
+	layout.NE.Layout(gtx, func(gtx C) D {
+		in := layout.Inset{Top: unit.Dp(4), Right: unit.Dp(4)}
+		return in.Layout(gtx, func(gtx C) D {
+			b := material.Button(th, s.clear, label)
+			b.TextSize = th.TextSize * 0.7
+			b.Inset = layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(2), Left: unit.Dp(6), Right: unit.Dp(6)}
+			b.Background = color.NRGBA{R: 0x80, G: 0x60, A: 0xc0}
+			return b.Layout(gtx)
+		})
+	})
+}
@@ -5,10 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
-	"gioui.org/gesture"
 	"gioui.org/io/clipboard"
 	"gioui.org/layout"
-	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
 	"gioui.org/widget"
@@ -24,6 +22,7 @@ import (
 	mrand "math/rand"
 	"runtime"
 	"sort"
+	"time"
 )
 
 // AddContactComplete is emitted when catshadow.NewContact has been called
@@ -36,6 +35,7 @@ var (
 	pasteIcon, _  = widget.NewIcon(icons.ContentContentPaste)
 	submitIcon, _ = widget.NewIcon(icons.NavigationCheck)
 	cancelIcon, _ = widget.NewIcon(icons.NavigationCancel)
+	wordsIcon, _  = widget.NewIcon(icons.ActionRecordVoiceOver)
 )
 
 // A contactal is a fractal and secret that represents a user identity
@@ -117,6 +117,11 @@ func (c *Contactal) Reset() {
 	c.SharedSecret = base64.StdEncoding.EncodeToString(b[:])
 }
 
+// ResetWords Re-Initializes the shared secret with words that can be read aloud.
+func (c *Contactal) ResetWords() {
+	c.SharedSecret = newWordSecret(secretWords)
+}
+
 // AddContactPage is the page for adding a new contact
 type AddContactPage struct {
 	a         *App
@@ -125,9 +130,11 @@ type AddContactPage struct {
 	contactal *Contactal
 	copy      *widget.Clickable
 	paste     *widget.Clickable
+	words     *widget.Clickable
+	wordMode  bool
 	back      *widget.Clickable
-	newAvatar *gesture.Click
-	newQr     *gesture.Click
+	newAvatar *widget.Clickable
+	newQr     *widget.Clickable
 	secret    *widget.Editor
 	submit    *widget.Clickable
 	cancel    *widget.Clickable
@@ -144,7 +151,7 @@ func (p *AddContactPage) Layout(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{Axis: layout.Vertical, Alignment: layout.End}.Layout(gtx,
 			layout.Rigid(func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Baseline}.Layout(gtx,
-					layout.Rigid(button(th, p.back, backIcon).Layout),
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					layout.Rigid(material.H6(th, "Add Contact").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout))
@@ -153,15 +160,20 @@ func (p *AddContactPage) Layout(gtx layout.Context) layout.Dimensions {
 			layout.Flexed(1, func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
 					layout.Flexed(1, func(gtx C) D {
-						return layout.Center.Layout(gtx, material.Editor(th, p.nickname, "Nickname").Layout)
+						return layout.Center.Layout(gtx, func(gtx C) D {
+							return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+								layout.Rigid(material.Editor(th, p.nickname, "Nickname").Layout),
+								layout.Rigid(func(gtx C) D {
+									if _, ok := getImport(p.a.c, p.nickname.Text()); ok {
+										return material.Caption(th, "Details will be restored from the address book").Layout(gtx)
+									}
+									return layout.Dimensions{}
+								}),
+							)
+						})
 					}),
 					layout.Flexed(1, func(gtx C) D {
-						dims := p.contactal.Layout(gtx)
-						a := clip.Rect(image.Rectangle{Max: dims.Size})
-						t := a.Push(gtx.Ops)
-						p.newAvatar.Add(gtx.Ops)
-						t.Pop()
-						return dims
+						return control(gtx, p.newAvatar, "Generate a new secret", p.contactal.Layout)
 					}),
 				)
 			}),
@@ -178,14 +190,17 @@ func (p *AddContactPage) Layout(gtx layout.Context) layout.Dimensions {
 									return layout.Center.Layout(gtx, material.Editor(th, p.secret, "Secret").Layout)
 								})
 							}),
+							// secret strength
+							layout.Rigid(p.layoutStrength),
 							// copy/paste
 							layout.Rigid(func(gtx C) D {
 								return layout.Center.Layout(gtx, func(gtx C) D {
 									return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.End}.Layout(gtx,
-										layout.Flexed(1, button(th, p.copy, copyIcon).Layout),
-										layout.Flexed(1, button(th, p.paste, pasteIcon).Layout),
-										layout.Flexed(1, button(th, p.submit, submitIcon).Layout),
-										layout.Flexed(1, button(th, p.cancel, cancelIcon).Layout),
+										layout.Flexed(1, button(th, p.copy, copyIcon, "Copy secret").Layout),
+										layout.Flexed(1, button(th, p.paste, pasteIcon, "Paste secret").Layout),
+										layout.Flexed(1, button(th, p.words, wordsIcon, "Switch between a random and a spoken secret").Layout),
+										layout.Flexed(1, button(th, p.submit, submitIcon, "Add contact").Layout),
+										layout.Flexed(1, button(th, p.cancel, cancelIcon, "Cancel").Layout),
 									)
 								})
 							}),
@@ -213,15 +228,18 @@ func (p *AddContactPage) Event(gtx layout.Context) interface{} {
 		}
 	}
 
-	for _, e := range p.newQr.Events(gtx.Queue) {
-		if e.Type == gesture.TypeClick {
-			p.contactal.Reset()
-			p.secret.SetText(p.contactal.SharedSecret)
-		}
+	if p.newQr.Clicked() {
+		p.resetSecret()
+	}
+
+	if p.words.Clicked() {
+		p.wordMode = !p.wordMode
+		p.resetSecret()
+		return RedrawEvent{}
 	}
 
 	if p.copy.Clicked() {
-		clipboard.WriteOp{Text: p.secret.Text()}.Add(gtx.Ops)
+		p.a.copySensitive(gtx, p.secret.Text())
 		return nil
 	}
 
@@ -236,12 +254,10 @@ func (p *AddContactPage) Event(gtx layout.Context) interface{} {
 		return RedrawEvent{}
 	}
 
-	for _, e := range p.newAvatar.Events(gtx.Queue) {
-		if e.Type == gesture.TypeClick {
-			p.contactal = NewContactal()
-			p.secret.SetText(p.contactal.SharedSecret)
-			return RedrawEvent{}
-		}
+	if p.newAvatar.Clicked() {
+		p.contactal = NewContactal()
+		p.resetSecret()
+		return RedrawEvent{}
 	}
 
 	for _, ev := range p.secret.Events() {
@@ -257,8 +273,7 @@ func (p *AddContactPage) Event(gtx layout.Context) interface{} {
 		return BackEvent{}
 	}
 	if p.submit.Clicked() {
-		if len(p.secret.Text()) < minPasswordLen {
-			p.secret.SetText("")
+		if secretEntropy(p.secret.Text()) < minSecretEntropy {
 			p.secret.Focus()
 			return nil
 		}
@@ -268,7 +283,8 @@ func (p *AddContactPage) Event(gtx layout.Context) interface{} {
 			return nil
 		}
 
-		p.a.c.NewContact(p.nickname.Text(), []byte(p.secret.Text()))
+		p.a.c.NewContact(p.nickname.Text(), []byte(normalizeSecret(p.secret.Text())))
+		setPandaStarted(p.a.c, p.nickname.Text(), time.Now())
 		b := &bytes.Buffer{}
 		sz := image.Point{X: gtx.Dp(unit.Dp(96)), Y: gtx.Dp(unit.Dp(96))}
 		i := p.contactal.Render(sz)
@@ -276,6 +292,10 @@ func (p *AddContactPage) Event(gtx layout.Context) interface{} {
 		if err := png.Encode(b, i); err == nil {
 			p.a.c.AddBlob("avatar://"+p.nickname.Text(), b.Bytes())
 		}
+		// restore details from an imported address book
+		if e, ok := getImport(p.a.c, p.nickname.Text()); ok {
+			applyImport(p.a.c, e)
+		}
 		return AddContactComplete{nickname: p.nickname.Text()}
 	}
 	return nil
@@ -293,11 +313,12 @@ func newAddContactPage(a *App) *AddContactPage {
 		p.secret.Submit = false
 	}
 
-	p.newAvatar = new(gesture.Click)
-	p.newQr = new(gesture.Click)
+	p.newAvatar = &widget.Clickable{}
+	p.newQr = &widget.Clickable{}
 	p.back = &widget.Clickable{}
 	p.copy = &widget.Clickable{}
 	p.paste = &widget.Clickable{}
+	p.words = &widget.Clickable{}
 	p.submit = &widget.Clickable{}
 	p.cancel = &widget.Clickable{}
 
@@ -308,78 +329,132 @@ func newAddContactPage(a *App) *AddContactPage {
 	return p
 }
 
+// resetSecret generates a new secret of the currently selected kind
+func (p *AddContactPage) resetSecret() {
+	if p.wordMode {
+		p.contactal.ResetWords()
+	} else {
+		p.contactal.Reset()
+	}
+	p.secret.SetText(p.contactal.SharedSecret)
+}
+
+// layoutStrength shows a meter of the estimated strength of the secret
+func (p *AddContactPage) layoutStrength(gtx C) D {
+	bits := secretEntropy(p.secret.Text())
+	var label string
+	pb := material.ProgressBar(th, float32(bits/(2*goodSecretEntropy)))
+	switch {
+	case bits < minSecretEntropy:
+		label = fmt.Sprintf("Too weak: ~%.0f bits", bits)
+		pb.Color = rgb(0xcc3333)
+	case bits < goodSecretEntropy:
+		label = fmt.Sprintf("Fair: ~%.0f bits", bits)
+		pb.Color = rgb(0xccaa33)
+	default:
+		label = fmt.Sprintf("Strong: ~%.0f bits", bits)
+		pb.Color = rgb(0x33aa55)
+	}
+	in := layout.Inset{Left: unit.Dp(8), Right: unit.Dp(8)}
+	return in.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(pb.Layout),
+			layout.Rigid(material.Caption(th, label).Layout),
+		)
+	})
+}
+
 func (p *AddContactPage) layoutQr(gtx C) D {
 	in := layout.Inset{}
-	dims := in.Layout(gtx, func(gtx C) D {
-		x := gtx.Constraints.Max.X
-		y := gtx.Constraints.Max.Y
-		if x > y {
-			x = y
-		}
-
-		sz := image.Point{X: x, Y: x}
-		gtx.Constraints = layout.Exact(gtx.Constraints.Constrain(sz))
-		qr, err := p.contactal.QR()
-		if err != nil {
-			return layout.Center.Layout(gtx, material.Caption(th, "QR").Layout)
-		}
-		qr.BackgroundColor = th.Bg
-		qr.ForegroundColor = th.Fg
+	return control(gtx, p.newQr, "QR code of the secret, generate a new secret", func(gtx C) D {
+		return in.Layout(gtx, func(gtx C) D {
+			x := gtx.Constraints.Max.X
+			y := gtx.Constraints.Max.Y
+			if x > y {
+				x = y
+			}
 
-		i := qr.Image(x)
-		return widget.Image{Fit: widget.ScaleDown, Src: paint.NewImageOp(i)}.Layout(gtx)
+			sz := image.Point{X: x, Y: x}
+			gtx.Constraints = layout.Exact(gtx.Constraints.Constrain(sz))
+			qr, err := p.contactal.QR()
+			if err != nil {
+				return layout.Center.Layout(gtx, material.Caption(th, "QR").Layout)
+			}
+			qr.BackgroundColor = th.Bg
+			qr.ForegroundColor = th.Fg
 
+			i := qr.Image(x)
+			return widget.Image{Fit: widget.ScaleDown, Src: paint.NewImageOp(i)}.Layout(gtx)
+		})
 	})
-	a := clip.Rect(image.Rectangle{Max: dims.Size})
-	t := a.Push(gtx.Ops)
-	p.newQr.Add(gtx.Ops)
-	t.Pop()
-	return dims
-
 }
 
 type sortedContacts []*catshadow.Contact
 
-func (s sortedContacts) Less(i, j int) bool {
-	// sorts contacts with messages most-recent-first, followed by contacts
-	// without messages alphabetically
-	if s[i].LastMessage == nil && s[j].LastMessage == nil {
-		return s[i].Nickname < s[j].Nickname
-	} else if s[i].LastMessage == nil {
-		return false
-	} else if s[j].LastMessage == nil {
-		return true
-	} else {
-		return s[i].LastMessage.Timestamp.After(s[j].LastMessage.Timestamp)
-	}
-}
-func (s sortedContacts) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
-}
-func (s sortedContacts) Len() int {
-	return len(s)
-}
-
 func getSortedContacts(a *App) (contacts sortedContacts) {
 	if a.c == nil {
 		return
 	}
 
 	// returns map[string]*Contact
-	for _, contact := range a.c.GetContacts() {
+	last := make(map[string]*catshadow.Message)
+	for nickname, contact := range a.c.GetContacts() {
 		contacts = append(contacts, contact)
+		// dropped messages do not move a blocked contact up
+		last[nickname] = lastVisibleMessage(a.c, contact)
 	}
-	sort.Sort(contacts)
+	// sorts contacts with messages most-recent-first, followed by contacts
+	// without messages alphabetically
+	sort.Slice(contacts, func(i, j int) bool {
+		mi, mj := last[contacts[i].Nickname], last[contacts[j].Nickname]
+		switch {
+		case mi == nil && mj == nil:
+			return contacts[i].Nickname < contacts[j].Nickname
+		case mi == nil:
+			return false
+		case mj == nil:
+			return true
+		default:
+			return mi.Timestamp.After(mj.Timestamp)
+		}
+	})
 	return
 }
 
-func button(th *material.Theme, button *widget.Clickable, icon *widget.Icon) material.IconButtonStyle {
+// contactBlobPrefixes are the prefixes of blobs stored per contact, which
+// follow the contact when renamed and are deleted with it. catshadow itself
+// only renames the avatar:// blob.
+var contactBlobPrefixes = []string{"avatar://", "verified://", "pending://", "lastread://",
+	pinnedFlag, archivedFlag, favoriteFlag, "notify://",
+	"notes://", "fields://", blockedFlag, droppedPrefix, "import://"}
+
+// renameContactBlobs moves the blobs of a renamed contact to the new nickname
+func renameContactBlobs(c *catshadow.Client, oldname, newname string) {
+	for _, prefix := range contactBlobPrefixes {
+		if b, err := c.GetBlob(prefix + oldname); err == nil {
+			c.AddBlob(prefix+newname, b)
+			c.DeleteBlob(prefix + oldname)
+		}
+	}
+}
+
+// deleteContactBlobs removes the blobs of a removed contact
+func deleteContactBlobs(c *catshadow.Client, nickname string) {
+	for _, prefix := range contactBlobPrefixes {
+		c.DeleteBlob(prefix + nickname)
+	}
+}
+
+// button returns an icon button, with the description that screen readers
+// announce in place of the icon
+func button(th *material.Theme, button *widget.Clickable, icon *widget.Icon, description string) material.IconButtonStyle {
 	return material.IconButtonStyle{
-		Background: th.Palette.Bg,
-		Color:      th.Palette.ContrastFg,
-		Icon:       icon,
-		Size:       unit.Dp(20),
-		Inset:      layout.UniformInset(unit.Dp(8)),
-		Button:     button,
+		Background:  th.Palette.Bg,
+		Color:       th.Palette.ContrastFg,
+		Icon:        icon,
+		Size:        unit.Dp(20),
+		Inset:       layout.UniformInset(unit.Dp(8)),
+		Button:      button,
+		Description: description,
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"gioui.org/io/clipboard"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
 	"gioui.org/layout"
 	"gioui.org/op/clip"
 	"gioui.org/unit"
@@ -35,7 +36,7 @@ type conversationPage struct {
 	a              *App
 	nickname       string
 	avatar         *widget.Image
-	edit           *gesture.Click
+	edit           *widget.Clickable
 	compose        *widget.Editor
 	send           *widget.Clickable
 	back           *widget.Clickable
@@ -44,7 +45,12 @@ type conversationPage struct {
 	msgpaste       *LongPress
 	msgdetails     *widget.Clickable
 	messageClicked *catshadow.Message
-	messageClicks  map[*catshadow.Message]*gesture.Click
+	messageClicks  map[*catshadow.Message]*widget.Clickable
+	texts          messageTexts
+}
+
+func (c *conversationPage) keyScopes() []string {
+	return []string{scopeConversation}
 }
 
 func (c *conversationPage) Start(stop <-chan struct{}) {
@@ -63,6 +69,9 @@ func (c *conversationPage) Event(gtx layout.Context) interface{} {
 	// receive keystroke to editor panel
 	for _, ev := range c.compose.Events() {
 		switch ev.(type) {
+		case widget.ChangeEvent:
+			// soft keyboards may type without key events
+			c.a.touch()
 		case widget.SubmitEvent:
 			c.send.Click()
 		}
@@ -77,35 +86,29 @@ func (c *conversationPage) Event(gtx layout.Context) interface{} {
 			c.compose.Focus()
 		}
 	}
-	key.InputOp{Tag: c, Keys: shortcuts}.Add(gtx.Ops)
+	key.InputOp{Tag: c, Keys: keymap.set(scopeConversation)}.Add(gtx.Ops)
 	for _, e := range gtx.Events(c) {
 		switch e := e.(type) {
 		case key.Event:
-			if e.Name == key.NameEscape && e.State == key.Release {
+			switch keymap.action(scopeConversation, e) {
+			case actionBack:
 				return BackEvent{}
-			}
-			if e.Name == key.NameF5 && e.State == key.Release {
+			case actionEditContact:
 				return EditContact{nickname: c.nickname}
-			}
-			if e.Name == key.NameUpArrow && e.State == key.Release {
+			case actionScrollUp:
 				messageList.ScrollToEnd = false
 				if messageList.Position.First > 0 {
 					messageList.Position.First = messageList.Position.First - 1
 				}
-
-			}
-			if e.Name == key.NameDownArrow && e.State == key.Release {
+			case actionScrollDown:
 				messageList.ScrollToEnd = true
 				messageList.Position.First = messageList.Position.First + 1
-			}
-			if e.Name == key.NamePageUp && e.State == key.Release {
+			case actionPageUp:
 				messageList.ScrollToEnd = false
 				if messageList.Position.First-messageList.Position.Count > 0 {
 					messageList.Position.First = messageList.Position.First - messageList.Position.Count
 				}
-
-			}
-			if e.Name == key.NamePageDown && e.State == key.Release {
+			case actionPageDown:
 				messageList.ScrollToEnd = true
 				messageList.Position.First = messageList.Position.First + messageList.Position.Count
 			}
@@ -136,16 +139,14 @@ func (c *conversationPage) Event(gtx layout.Context) interface{} {
 		msgId := c.a.c.SendMessage(c.nickname, msg)
 		return MessageSent{nickname: c.nickname, msgId: msgId}
 	}
-	for _, e := range c.edit.Events(gtx.Queue) {
-		if e.Type == gesture.TypeClick {
-			return EditContact{nickname: c.nickname}
-		}
+	if c.edit.Clicked() {
+		return EditContact{nickname: c.nickname}
 	}
 	if c.back.Clicked() {
 		return BackEvent{}
 	}
 	if c.msgcopy.Clicked() {
-		clipboard.WriteOp{Text: string(c.messageClicked.Plaintext)}.Add(gtx.Ops)
+		c.a.copySensitive(gtx, c.texts.text(c.messageClicked))
 		c.messageClicked = nil
 		return nil
 	}
@@ -154,10 +155,8 @@ func (c *conversationPage) Event(gtx layout.Context) interface{} {
 	}
 
 	for msg, click := range c.messageClicks {
-		for _, e := range click.Events(gtx.Queue) {
-			if e.Type == gesture.TypeClick {
-				c.messageClicked = msg
-			}
+		if click.Clicked() {
+			c.messageClicked = msg
 		}
 	}
 
@@ -170,7 +169,7 @@ func (c *conversationPage) Event(gtx layout.Context) interface{} {
 	return nil
 }
 
-func layoutMessage(gtx C, msg *catshadow.Message, isSelected bool, expires time.Duration) D {
+func layoutMessage(gtx C, msg *catshadow.Message, text string, isSelected bool, expires time.Duration) D {
 
 	var statusIcon *widget.Icon
 	if msg.Outbound == true {
@@ -187,7 +186,7 @@ func layoutMessage(gtx C, msg *catshadow.Message, isSelected bool, expires time.
 	}
 
 	return layout.Flex{Axis: layout.Vertical, Alignment: layout.End, Spacing: layout.SpaceBetween}.Layout(gtx,
-		layout.Rigid(material.Body1(th, string(msg.Plaintext)).Layout),
+		layout.Rigid(material.Body1(th, text).Layout),
 		layout.Rigid(func(gtx C) D {
 			in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(0), Left: unit.Dp(8), Right: unit.Dp(8)}
 			return in.Layout(gtx, func(gtx C) D {
@@ -228,13 +227,10 @@ func layoutMessage(gtx C, msg *catshadow.Message, isSelected bool, expires time.
 
 func (c *conversationPage) Layout(gtx layout.Context) layout.Dimensions {
 	contact := c.a.c.GetContacts()[c.nickname]
-	if n, ok := notifications[c.nickname]; ok {
-		if c.a.focus {
-			n.Cancel()
-			delete(notifications, c.nickname)
-		}
+	if c.a.focus {
+		notifications.dismiss(c.nickname)
 	}
-	messages := c.a.c.GetSortedConversation(c.nickname)
+	messages := visibleMessages(c.a.c, c.nickname, c.a.c.GetSortedConversation(c.nickname))
 	expires, _ := c.a.c.GetExpiration(c.nickname)
 	bgl := Background{
 		Color: th.Bg,
@@ -245,20 +241,20 @@ func (c *conversationPage) Layout(gtx layout.Context) layout.Dimensions {
 		layout.Rigid(func(gtx C) D {
 			return bgl.Layout(gtx, func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
-					layout.Rigid(button(th, c.back, backIcon).Layout),
+					layout.Rigid(button(th, c.back, backIcon, "Back").Layout),
 					layout.Rigid(func(gtx C) D {
-						dims := layoutAvatar(gtx, c.a.c, c.nickname)
-						a := clip.Rect(image.Rectangle{Max: dims.Size})
-						t := a.Push(gtx.Ops)
-						c.edit.Add(gtx.Ops)
-						t.Pop()
-						return dims
+						return control(gtx, c.edit, "Edit contact "+c.nickname, func(gtx C) D {
+							return layoutAvatar(gtx, c.a.c, c.nickname)
+						})
 					}),
 					layout.Rigid(material.Caption(th, c.nickname).Layout),
 					layout.Rigid(func(gtx C) D {
 						if contact.IsPending {
 							return pandaIcon.Layout(gtx, th.Palette.ContrastFg)
 						}
+						if isVerified(c.a.c, c.nickname) {
+							return verifiedIcon.Layout(gtx, th.Palette.ContrastFg)
+						}
 						return layout.Dimensions{}
 					}),
 					layout.Flexed(1, fill{th.Bg}.Layout),
@@ -274,16 +270,16 @@ func (c *conversationPage) Layout(gtx layout.Context) layout.Dimensions {
 
 				dims := messageList.Layout(gtx, len(messages), func(gtx C, i int) layout.Dimensions {
 					if _, ok := c.messageClicks[messages[i]]; !ok {
-						c.messageClicks[messages[i]] = new(gesture.Click)
+						c.messageClicks[messages[i]] = &widget.Clickable{}
 					}
 
 					bgSender := Background{
-						Color:  th.ContrastBg,
+						Color:  activeTheme.Sent,
 						Inset:  layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(8), Right: unit.Dp(12)},
 						Radius: unit.Dp(10),
 					}
 					bgReceiver := Background{
-						Color:  th.ContrastFg,
+						Color:  activeTheme.Received,
 						Inset:  layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(8)},
 						Radius: unit.Dp(10),
 					}
@@ -293,37 +289,33 @@ func (c *conversationPage) Layout(gtx layout.Context) layout.Dimensions {
 							inbetween = layout.Inset{Top: unit.Dp(8)}
 						}
 					}
-					var dims D
 					isSelected := messages[i] == c.messageClicked
-					if messages[i].Outbound {
-						dims = layout.Flex{Axis: layout.Horizontal, Alignment: layout.Baseline, Spacing: layout.SpaceAround}.Layout(gtx,
-							layout.Flexed(1, fill{th.Bg}.Layout),
-							layout.Flexed(5, func(gtx C) D {
-								return inbetween.Layout(gtx, func(gtx C) D {
-									return bgSender.Layout(gtx, func(gtx C) D {
-										return layoutMessage(gtx, messages[i], isSelected, expires)
+					// the message is announced with its sender and delivery status, then its text
+					return control(gtx, c.messageClicks[messages[i]], messageDescription(messages[i], c.nickname), func(gtx C) D {
+						semantic.SelectedOp(isSelected).Add(gtx.Ops)
+						if messages[i].Outbound {
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Baseline, Spacing: layout.SpaceAround}.Layout(gtx,
+								layout.Flexed(1, fill{th.Bg}.Layout),
+								layout.Flexed(5, func(gtx C) D {
+									return inbetween.Layout(gtx, func(gtx C) D {
+										return bgSender.Layout(gtx, func(gtx C) D {
+											return layoutMessage(gtx, messages[i], c.texts.text(messages[i]), isSelected, expires)
+										})
 									})
-								})
-							}),
-						)
-					} else {
-						dims = layout.Flex{Axis: layout.Horizontal, Alignment: layout.Baseline, Spacing: layout.SpaceAround}.Layout(gtx,
+								}),
+							)
+						}
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Baseline, Spacing: layout.SpaceAround}.Layout(gtx,
 							layout.Flexed(5, func(gtx C) D {
 								return inbetween.Layout(gtx, func(gtx C) D {
 									return bgReceiver.Layout(gtx, func(gtx C) D {
-										return layoutMessage(gtx, messages[i], isSelected, expires)
+										return layoutMessage(gtx, messages[i], c.texts.text(messages[i]), isSelected, expires)
 									})
 								})
 							}),
 							layout.Flexed(1, fill{th.Bg}.Layout),
 						)
-					}
-					a := clip.Rect(image.Rectangle{Max: dims.Size})
-					t := a.Push(gtx.Ops)
-					c.messageClicks[messages[i]].Add(gtx.Ops)
-					t.Pop()
-					return dims
-
+					})
 				})
 				if c.messageClicked != nil {
 					a := clip.Rect(image.Rectangle{Max: dims.Size})
@@ -373,7 +365,7 @@ func (c *conversationPage) Layout(gtx layout.Context) layout.Dimensions {
 						return dims
 					}),
 					layout.Rigid(func(gtx C) D {
-						return layout.Inset{Left: unit.Dp(8), Right: unit.Dp(8)}.Layout(gtx, button(th, c.send, sendIcon).Layout)
+						return layout.Inset{Left: unit.Dp(8), Right: unit.Dp(8)}.Layout(gtx, button(th, c.send, sendIcon, "Send message").Layout)
 					}),
 				)
 			})
@@ -389,15 +381,17 @@ func newConversationPage(a *App, nickname string) *conversationPage {
 
 	p := &conversationPage{a: a, nickname: nickname,
 		compose:       ed,
-		messageClicks: make(map[*catshadow.Message]*gesture.Click),
+		messageClicks: make(map[*catshadow.Message]*widget.Clickable),
+		texts:         make(messageTexts),
 		back:          &widget.Clickable{},
 		msgcopy:       &widget.Clickable{},
 		msgpaste:      NewLongPress(a.w.Invalidate, 800*time.Millisecond),
 		msgdetails:    &widget.Clickable{},
 		cancel:        new(gesture.Click),
 		send:          &widget.Clickable{},
-		edit:          new(gesture.Click),
+		edit:          &widget.Clickable{},
 	}
 	p.compose.Focus()
+	markRead(a.c, nickname)
 	return p
 }
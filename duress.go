@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/core/crypto/rand"
+	"github.com/katzenpost/katzenpost/core/utils"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	// duressSlotSuffix names the second statefile of a profile. It always
+	// exists beside the statefile, holding either the decoy or random bytes
+	// that cannot be told apart from an encrypted statefile.
+	duressSlotSuffix = ".1"
+	// duressWipeBlob is stored in a decoy that destroys the real statefile
+	// when it is opened. It is removed as soon as the decoy is unlocked.
+	duressWipeBlob = "DuressWipe"
+	// duressPaddingBlob pads a decoy to the size of the random filler that
+	// it replaces, so that setting a duress passphrase does not change the
+	// size of the slot
+	duressPaddingBlob = "Padding"
+	// minSlotSize is the size of the random filler of a new profile
+	minSlotSize = 4096
+)
+
+// activeStatefile is the statefile of the unlocked client, which is the
+// decoy when the duress passphrase was entered
+var activeStatefile string
+
+var errDuressDecoy = errors.New("Could not change the duress passphrase")
+
+// duressSlot returns the path of the decoy statefile
+func duressSlot(statefile string) string {
+	return statefile + duressSlotSuffix
+}
+
+// writeRandom fills the file at path with n random bytes
+func writeRandom(path string, n int) error {
+	b := make([]byte, n)
+	if _, err := rand.Reader.Read(b); err != nil {
+		return err
+	}
+	return writeFileSync(path, b)
+}
+
+// slotSize returns the size of the decoy slot of statefile, or the size of
+// the filler of a new slot if it does not exist
+func slotSize(statefile string) int {
+	if fi, err := os.Stat(duressSlot(statefile)); err == nil {
+		return int(fi.Size())
+	}
+	size := minSlotSize
+	if fi, err := os.Stat(statefile); err == nil && int(fi.Size()) > size {
+		size = int(fi.Size())
+	}
+	return size
+}
+
+// fillSlot overwrites the decoy slot of statefile and its catshadow backup
+// file with size random bytes
+func fillSlot(statefile string, size int) error {
+	slot := duressSlot(statefile)
+	if err := writeRandom(slot+"~", size); err != nil {
+		return err
+	}
+	return writeRandom(slot, size)
+}
+
+// ensureDuressSlot fills the decoy slot with random bytes if it does not
+// exist, so that every profile has a second statefile whether or not a
+// duress passphrase is set. The catshadow backup file of the slot is created
+// as well, as it exists beside a statefile that has been used.
+func ensureDuressSlot(statefile string) error {
+	if _, err := os.Stat(duressSlot(statefile)); err == nil {
+		return nil
+	}
+	return fillSlot(statefile, slotSize(statefile))
+}
+
+// padState marshals state with a padding blob that makes it encrypt to size
+// bytes, or to a few bytes more when a CBOR length header grows with the
+// padding
+func padState(state *catshadow.State, size int) ([]byte, error) {
+	pad := 0
+	for {
+		state.Blob[duressPaddingBlob] = make([]byte, pad)
+		b, err := cbor.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		n := len(b) + stateNonceSize + secretbox.Overhead
+		if n >= size {
+			return b, nil
+		}
+		utils.ExplicitBzero(b)
+		pad += size - n
+	}
+}
+
+// destroyStatefile overwrites the statefile and its catshadow backup with
+// random bytes of the same size, leaving files that look like it. This is
+// best effort: storage that remaps writes may keep the old contents.
+func destroyStatefile(statefile string) {
+	for _, path := range []string{statefile, statefile + "~"} {
+		if fi, err := os.Stat(path); err == nil {
+			writeRandom(path, int(fi.Size()))
+		}
+	}
+}
+
+// hasDuressPassphrase returns true if the unlocked profile has a decoy
+func hasDuressPassphrase(c *catshadow.Client) bool {
+	_, err := c.GetBlob("DuressPassphrase")
+	return err == nil
+}
+
+// setDuressPassphrase creates a new decoy statefile encrypted with passphrase
+// in the slot of the statefile, replacing any previous decoy
+func setDuressPassphrase(statefile string, passphrase []byte, wipe bool) error {
+	if state, err := decryptStatefile(statefile, passphrase); err == nil {
+		utils.ExplicitBzero(state)
+		return errors.New("The duress passphrase must differ from your passphrase")
+	}
+	state := &catshadow.State{
+		Contacts:      make([]*catshadow.Contact, 0),
+		Conversations: make(map[string]map[catshadow.MessageID]*catshadow.Message),
+		Blob:          defaultSettings(profileConfigPath()),
+	}
+	if wipe {
+		state.Blob[duressWipeBlob] = []byte{1}
+	}
+	b, err := padState(state, slotSize(statefile))
+	if err != nil {
+		return err
+	}
+	defer utils.ExplicitBzero(b)
+	raw, err := encryptState(b, passphrase)
+	if err != nil {
+		return err
+	}
+	// the backup file of a used statefile is as large as the statefile
+	slot := duressSlot(statefile)
+	if err := writeRandom(slot+"~", len(raw)); err != nil {
+		return err
+	}
+	if err := writeFileSync(slot+".tmp", raw); err != nil {
+		return err
+	}
+	return os.Rename(slot+".tmp", slot)
+}
+
+// DuressPage sets or removes the duress passphrase of the unlocked profile
+type DuressPage struct {
+	a          *App
+	back       *widget.Clickable
+	submit     *widget.Clickable
+	remove     *widget.Clickable
+	passphrase *widget.Editor
+	confirm    *widget.Editor
+	wipe       *widget.Bool
+	status     string
+}
+
+// ShowDuressClick is the event that requests the duress passphrase page
+type ShowDuressClick struct{}
+
+// Layout returns the duress passphrase fields
+func (p *DuressPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Duress Passphrase").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					children := []layout.FlexChild{
+						layout.Rigid(material.Body2(th, "Signing in with the duress passphrase opens a separate, empty decoy profile instead of this one. Sign in with it to add contacts, so that it looks used. Nothing on disk shows whether a decoy exists.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.passphrase, "Duress passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.confirm, "Confirm duress passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.CheckBox(th, p.wipe, "Destroy this profile's statefile when the duress passphrase is used").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+					}
+					buttons := []layout.FlexChild{layout.Rigid(material.Button(th, p.submit, "Set Duress Passphrase").Layout)}
+					if hasDuressPassphrase(p.a.c) {
+						buttons = append(buttons, layout.Rigid(material.Button(th, p.remove, "Remove").Layout))
+					}
+					children = append(children,
+						layout.Rigid(func(gtx C) D {
+							return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceEvenly}.Layout(gtx, buttons...)
+						}),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+				})
+			}),
+		)
+	})
+}
+
+// Event sets or removes the duress passphrase
+func (p *DuressPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.submit.Clicked() {
+		statefile, err := statefilePath()
+		switch {
+		case len(p.passphrase.Text()) < minPasswordLen:
+			p.status = fmt.Sprintf("Password must be minimum %d characters long", minPasswordLen)
+			return RedrawEvent{}
+		case p.passphrase.Text() != p.confirm.Text():
+			p.status = "The duress passphrases do not match"
+			p.confirm.SetText("")
+			return RedrawEvent{}
+		case err != nil:
+			p.status = err.Error()
+			return RedrawEvent{}
+		case activeStatefile != statefile:
+			// the decoy cannot replace the slot holding it
+			p.status = errDuressDecoy.Error()
+			return RedrawEvent{}
+		}
+		passphrase := []byte(p.passphrase.Text())
+		err = setDuressPassphrase(statefile, passphrase, p.wipe.Value)
+		utils.ExplicitBzero(passphrase)
+		if err != nil {
+			p.status = err.Error()
+			return RedrawEvent{}
+		}
+		p.a.c.AddBlob("DuressPassphrase", []byte{1})
+		p.passphrase.SetText("")
+		p.confirm.SetText("")
+		p.status = "Duress passphrase set"
+		return RedrawEvent{}
+	}
+	if p.remove.Clicked() {
+		statefile, err := statefilePath()
+		if err != nil || activeStatefile != statefile {
+			p.status = errDuressDecoy.Error()
+			return RedrawEvent{}
+		}
+		if err := fillSlot(statefile, slotSize(statefile)); err != nil {
+			p.status = err.Error()
+			return RedrawEvent{}
+		}
+		p.a.c.DeleteBlob("DuressPassphrase")
+		p.status = "Duress passphrase removed"
+		return RedrawEvent{}
+	}
+	return nil
+}
+
+func (p *DuressPage) Start(stop <-chan struct{}) {
+}
+
+func newDuressPage(a *App) *DuressPage {
+	return &DuressPage{a: a,
+		back:       &widget.Clickable{},
+		submit:     &widget.Clickable{},
+		remove:     &widget.Clickable{},
+		passphrase: &widget.Editor{SingleLine: true, Mask: '*'},
+		confirm:    &widget.Editor{SingleLine: true, Mask: '*'},
+		wipe:       &widget.Bool{},
+	}
+}
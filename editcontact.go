@@ -1,14 +1,11 @@
 package main
 
 import (
-	"gioui.org/gesture"
 	"gioui.org/layout"
-	"gioui.org/op/clip"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 	"github.com/hako/durafmt"
-	"image"
 	"math"
 	"time"
 )
@@ -19,11 +16,18 @@ type EditContactPage struct {
 	nickname string
 	back     *widget.Clickable
 	apply    *widget.Clickable
-	avatar   *gesture.Click
+	avatar   *widget.Clickable
 	clear    *widget.Clickable
 	expiry   *widget.Float
 	rename   *widget.Clickable
 	remove   *widget.Clickable
+	verify   *widget.Clickable
+	notes    *widget.Clickable
+	pinned   *widget.Bool
+	archived *widget.Bool
+	favorite *widget.Bool
+	blocked  *widget.Bool
+	notify   *widget.Enum
 	settings *layout.List
 	widgets  []layout.Widget
 	duration time.Duration
@@ -46,7 +50,7 @@ func (p *EditContactPage) Layout(gtx layout.Context) layout.Dimensions {
 			// topbar
 			layout.Rigid(func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
-					layout.Rigid(button(th, p.back, backIcon).Layout),
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					layout.Rigid(material.H6(th, "Edit Contact").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout))
@@ -82,10 +86,8 @@ func (p *EditContactPage) Event(gtx layout.Context) interface{} {
 	if p.back.Clicked() {
 		return BackEvent{}
 	}
-	for _, e := range p.avatar.Events(gtx.Queue) {
-		if e.Type == gesture.TypeClick {
-			return ChooseAvatar{nickname: p.nickname}
-		}
+	if p.avatar.Clicked() {
+		return ChooseAvatar{nickname: p.nickname}
 	}
 	if p.clear.Clicked() {
 		// TODO: confirmation dialog
@@ -100,10 +102,31 @@ func (p *EditContactPage) Event(gtx layout.Context) interface{} {
 	if p.rename.Clicked() {
 		return RenameContact{nickname: p.nickname}
 	}
+	if p.verify.Clicked() {
+		return VerifyContact{nickname: p.nickname}
+	}
+	if p.notes.Clicked() {
+		return ContactNotes{nickname: p.nickname}
+	}
+	if p.pinned.Changed() {
+		setFlag(p.a.c, pinnedFlag, p.nickname, p.pinned.Value)
+	}
+	if p.archived.Changed() {
+		setFlag(p.a.c, archivedFlag, p.nickname, p.archived.Value)
+	}
+	if p.favorite.Changed() {
+		setFlag(p.a.c, favoriteFlag, p.nickname, p.favorite.Value)
+	}
+	if p.blocked.Changed() {
+		setFlag(p.a.c, blockedFlag, p.nickname, p.blocked.Value)
+	}
+	if p.notify.Changed() {
+		setNotifyRule(p.a.c, p.nickname, notifyRuleFor(p.notify.Value))
+	}
 	if p.remove.Clicked() {
 		// TODO: confirmation dialog
 		p.a.c.RemoveContact(p.nickname)
-		p.a.c.DeleteBlob("avatar://" + p.nickname)
+		deleteContactBlobs(p.a.c, p.nickname)
 		// remove avatar cache
 		delete(avatars, p.nickname)
 		return EditContactComplete{nickname: p.nickname}
@@ -118,25 +141,72 @@ func (p *EditContactPage) Event(gtx layout.Context) interface{} {
 func (p *EditContactPage) Start(stop <-chan struct{}) {
 }
 
+// notifyRuleFor returns the notification rule for a choice on EditContactPage
+func notifyRuleFor(choice string) notifyRule {
+	switch choice {
+	case "1h":
+		return notifyRule{Mode: notifyMuteUntil, Until: time.Now().Add(time.Hour)}
+	case "8h":
+		return notifyRule{Mode: notifyMuteUntil, Until: time.Now().Add(8 * time.Hour)}
+	case "week":
+		return notifyRule{Mode: notifyMuteUntil, Until: time.Now().Add(7 * 24 * time.Hour)}
+	case "forever":
+		return notifyRule{Mode: notifyMuteForever}
+	case "badge":
+		return notifyRule{Mode: notifyBadgeOnly}
+	default:
+		return notifyRule{Mode: notifyAlways}
+	}
+}
+
+// notifyEnumValue returns the choice on EditContactPage that shows a rule.
+// Temporary mutes select no choice, as the chosen period is not stored.
+func notifyEnumValue(r notifyRule) string {
+	switch r.Mode {
+	case notifyMuteForever:
+		return "forever"
+	case notifyBadgeOnly:
+		return "badge"
+	case notifyMuteUntil:
+		return ""
+	default:
+		return "always"
+	}
+}
+
+// flagSwitch returns a labelled switch for a contact flag
+func flagSwitch(b *widget.Bool, label string) layout.Widget {
+	return func(gtx C) D {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, material.Body2(th, label).Layout),
+			layout.Rigid(material.Switch(th, b, label).Layout),
+		)
+	}
+}
+
 func newEditContactPage(a *App, contact string) *EditContactPage {
 	expiry, _ := a.c.GetExpiration(contact)
 	p := &EditContactPage{a: a, nickname: contact, back: &widget.Clickable{},
-		avatar: &gesture.Click{}, clear: &widget.Clickable{},
+		avatar: &widget.Clickable{}, clear: &widget.Clickable{},
 		expiry: &widget.Float{}, rename: &widget.Clickable{},
 		remove: &widget.Clickable{}, apply: &widget.Clickable{},
+		verify:   &widget.Clickable{},
+		notes:    &widget.Clickable{},
+		pinned:   &widget.Bool{Value: hasFlag(a.c, pinnedFlag, contact)},
+		archived: &widget.Bool{Value: hasFlag(a.c, archivedFlag, contact)},
+		favorite: &widget.Bool{Value: hasFlag(a.c, favoriteFlag, contact)},
+		blocked:  &widget.Bool{Value: isBlocked(a.c, contact)},
+		notify:   &widget.Enum{Value: notifyEnumValue(getNotifyRule(a.c, contact))},
 		settings: &layout.List{Axis: layout.Vertical},
 	}
 	p.expiry.Value = float32(math.Round(float64(expiry) / float64(time.Minute*60*24)))
 	p.widgets = []layout.Widget{
 		func(gtx C) D {
-			dims := layout.Center.Layout(gtx, func(gtx C) D {
-				return layoutAvatar(gtx, p.a.c, p.nickname)
+			return layout.Center.Layout(gtx, func(gtx C) D {
+				return control(gtx, p.avatar, "Change avatar of "+p.nickname, func(gtx C) D {
+					return layoutAvatar(gtx, p.a.c, p.nickname)
+				})
 			})
-			a := clip.Rect(image.Rectangle{Max: dims.Size})
-			t := a.Push(gtx.Ops)
-			p.avatar.Add(gtx.Ops)
-			t.Pop()
-			return dims
 		},
 		layout.Spacer{Height: unit.Dp(8)}.Layout,
 		func(gtx C) D {
@@ -153,10 +223,35 @@ func newEditContactPage(a *App, contact string) *EditContactPage {
 			)
 		},
 		layout.Spacer{Height: unit.Dp(8)}.Layout,
+		flagSwitch(p.pinned, "Pin to top"),
+		flagSwitch(p.favorite, "Favorite"),
+		flagSwitch(p.archived, "Archived"),
+		flagSwitch(p.blocked, "Blocked"),
+		layout.Spacer{Height: unit.Dp(8)}.Layout,
+		func(gtx C) D {
+			label := "Notifications"
+			if r := getNotifyRule(p.a.c, p.nickname); r.Mode == notifyMuteUntil {
+				label = "Notifications: muted until " + r.Until.Format("Mon 15:04")
+			}
+			return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+				layout.Rigid(material.Body2(th, label).Layout),
+				layout.Rigid(material.RadioButton(th, p.notify, "always", "Always").Layout),
+				layout.Rigid(material.RadioButton(th, p.notify, "1h", "Mute for 1 hour").Layout),
+				layout.Rigid(material.RadioButton(th, p.notify, "8h", "Mute for 8 hours").Layout),
+				layout.Rigid(material.RadioButton(th, p.notify, "week", "Mute for 1 week").Layout),
+				layout.Rigid(material.RadioButton(th, p.notify, "forever", "Mute forever").Layout),
+				layout.Rigid(material.RadioButton(th, p.notify, "badge", "Badge only").Layout),
+			)
+		},
+		layout.Spacer{Height: unit.Dp(8)}.Layout,
 		material.Button(th, p.clear, "Clear History").Layout,
 		layout.Spacer{Height: unit.Dp(8)}.Layout,
 		material.Button(th, p.rename, "Rename Contact").Layout,
 		layout.Spacer{Height: unit.Dp(8)}.Layout,
+		material.Button(th, p.verify, "Verify Contact").Layout,
+		layout.Spacer{Height: unit.Dp(8)}.Layout,
+		material.Button(th, p.notes, "Notes and Details").Layout,
+		layout.Spacer{Height: unit.Dp(8)}.Layout,
 		material.Button(th, p.remove, "Delete Contact").Layout,
 		layout.Spacer{Height: unit.Dp(8)}.Layout,
 		material.Button(th, p.apply, "Apply Changes").Layout,
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"image/color"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+// sortMode is the order in which HomePage lists contacts
+type sortMode int
+
+const (
+	sortRecent sortMode = iota
+	sortAlpha
+	sortUnread
+	sortPending
+	numSortModes
+)
+
+func (m sortMode) String() string {
+	switch m {
+	case sortAlpha:
+		return "A-Z"
+	case sortUnread:
+		return "Unread"
+	case sortPending:
+		return "Pending"
+	default:
+		return "Recent"
+	}
+}
+
+// contactFilter holds the search, sort and filter state of the contact list.
+// It lives at package level like selectedIdx so that it survives HomePage
+// being recreated.
+type contactFilter struct {
+	query    string
+	sort     sortMode
+	pending  bool
+	verified bool
+	favorite bool
+	muted    bool
+}
+
+var homeFilter = &contactFilter{}
+
+// active returns true if any contacts may be hidden by the filter
+func (f *contactFilter) active() bool {
+	return f.query != "" || f.pending || f.verified || f.favorite || f.muted
+}
+
+// match returns true if the contact passes the filter chips and its nickname
+// fuzzily matches the search, or its notes or fields contain it
+func (f *contactFilter) match(c *catshadow.Client, contact *catshadow.Contact) bool {
+	if f.pending && !contact.IsPending {
+		return false
+	}
+	if f.verified && !isVerified(c, contact.Nickname) {
+		return false
+	}
+	if f.favorite && !hasFlag(c, favoriteFlag, contact.Nickname) {
+		return false
+	}
+	if f.muted && !isMuted(c, contact.Nickname) {
+		return false
+	}
+	if f.query == "" {
+		return true
+	}
+	return fuzzyMatch(f.query, contact.Nickname) || notesContain(c, contact.Nickname, f.query)
+}
+
+// fuzzyMatch returns true if the characters of query appear in s in order,
+// ignoring case and whitespace, so "jdoe" matches "John Doe"
+func fuzzyMatch(query, s string) bool {
+	target := []rune(strings.ToLower(s))
+	i := 0
+	for _, r := range strings.ToLower(query) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for i < len(target) && target[i] != r {
+			i++
+		}
+		if i == len(target) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// lastRead returns when the conversation with a contact was last viewed
+func lastRead(c *catshadow.Client, nickname string) time.Time {
+	var t time.Time
+	if b, err := c.GetBlob("lastread://" + nickname); err == nil {
+		t.UnmarshalBinary(b)
+	}
+	return t
+}
+
+// markRead records that the conversation with a contact has been viewed
+func markRead(c *catshadow.Client, nickname string) {
+	if b, err := time.Now().MarshalBinary(); err == nil {
+		c.AddBlob("lastread://"+nickname, b)
+	}
+}
+
+// isUnread returns true if the contact's last message was received after the conversation was last viewed
+func isUnread(c *catshadow.Client, contact *catshadow.Contact) bool {
+	last := lastVisibleMessage(c, contact)
+	if last == nil || last.Outbound {
+		return false
+	}
+	return last.Timestamp.After(lastRead(c, contact.Nickname))
+}
+
+// getFilteredContacts returns the contacts matching the filter, in the
+// selected order with pinned contacts first. Archived contacts are returned
+// separately.
+func getFilteredContacts(a *App, f *contactFilter) (contacts, archived sortedContacts) {
+	contacts = make(sortedContacts, 0)
+	archived = make(sortedContacts, 0)
+	for _, contact := range sortContacts(a, f) {
+		if hasFlag(a.c, archivedFlag, contact.Nickname) {
+			archived = append(archived, contact)
+		} else {
+			contacts = append(contacts, contact)
+		}
+	}
+	pinned := make(map[string]bool)
+	for _, contact := range contacts {
+		pinned[contact.Nickname] = hasFlag(a.c, pinnedFlag, contact.Nickname)
+	}
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return pinned[contacts[i].Nickname] && !pinned[contacts[j].Nickname]
+	})
+	return
+}
+
+// sortContacts returns the contacts matching the filter, in the selected order
+func sortContacts(a *App, f *contactFilter) sortedContacts {
+	contacts := make(sortedContacts, 0)
+	for _, contact := range getSortedContacts(a) {
+		if f.match(a.c, contact) {
+			contacts = append(contacts, contact)
+		}
+	}
+	switch f.sort {
+	case sortAlpha:
+		sort.SliceStable(contacts, func(i, j int) bool {
+			return strings.ToLower(contacts[i].Nickname) < strings.ToLower(contacts[j].Nickname)
+		})
+	case sortUnread:
+		unread := make(map[string]bool)
+		for _, contact := range contacts {
+			unread[contact.Nickname] = isUnread(a.c, contact)
+		}
+		sort.SliceStable(contacts, func(i, j int) bool {
+			return unread[contacts[i].Nickname] && !unread[contacts[j].Nickname]
+		})
+	case sortPending:
+		sort.SliceStable(contacts, func(i, j int) bool {
+			return contacts[i].IsPending && !contacts[j].IsPending
+		})
+	}
+	return contacts
+}
+
+// chip is a small toggle button used for the filter and sort controls
+func chip(th *material.Theme, click *widget.Clickable, label string, selected bool) layout.Widget {
+	return func(gtx C) D {
+		b := material.Button(th, click, label)
+		b.TextSize = th.TextSize * 0.8
+		b.Inset = layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(8), Right: unit.Dp(8)}
+		b.CornerRadius = unit.Dp(12)
+		if !selected {
+			b.Background = color.NRGBA{A: 0x20}
+			b.Color = th.Fg
+		}
+		in := layout.Inset{Right: unit.Dp(4)}
+		return in.Layout(gtx, b.Layout)
+	}
+}
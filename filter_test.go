@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, s string
+		want     bool
+	}{
+		{"", "John Doe", true},
+		{"jdoe", "John Doe", true},
+		{"JD", "john doe", true},
+		{"john doe", "JohnDoe", true},
+		{"hn", "John Doe", true},
+		{"doej", "John Doe", false},
+		{"jj", "John Doe", false},
+		{"x", "John Doe", false},
+		{"a", "", false},
+		{"é", "José", true},
+	}
+	for _, test := range tests {
+		if got := fuzzyMatch(test.query, test.s); got != test.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", test.query, test.s, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"gioui.org/widget"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/core/utils"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// prefixes of the per-contact flags that organize the contact list
+const (
+	pinnedFlag   = "pinned://"
+	archivedFlag = "archived://"
+	favoriteFlag = "favorite://"
+	blockedFlag  = "blocked://"
+	// droppedPrefix names the blob listing the messages dropped from a contact
+	droppedPrefix = "dropped://"
+)
+
+var (
+	pinIcon, _      = widget.NewIcon(icons.ActionBookmark)
+	favoriteIcon, _ = widget.NewIcon(icons.ToggleStar)
+	archiveIcon, _  = widget.NewIcon(icons.ContentArchive)
+	expandIcon, _   = widget.NewIcon(icons.NavigationExpandMore)
+	collapseIcon, _ = widget.NewIcon(icons.NavigationExpandLess)
+	blockedIcon, _  = widget.NewIcon(icons.ContentBlock)
+)
+
+// hasFlag returns true if the flag is set for the contact
+func hasFlag(c *catshadow.Client, flag, nickname string) bool {
+	_, err := c.GetBlob(flag + nickname)
+	return err == nil
+}
+
+// isBlocked returns true if messages from the contact are dropped
+func isBlocked(c *catshadow.Client, nickname string) bool {
+	return hasFlag(c, blockedFlag, nickname)
+}
+
+// droppedPrecision is how close the receive time of a message must be to a
+// recorded drop to match it. catshadow saves times as floating point seconds,
+// which move them by up to a microsecond, while the messages of a contact are
+// decrypted one at a time and much further apart.
+const droppedPrecision = 10 * time.Microsecond
+
+// dropMessage hides a message received from a blocked contact. catshadow
+// does not tell the message ID, so the message is recorded by the time it
+// was received, which catshadow sets when it decrypts each message. The
+// plaintext is shared with the state that catshadow saves, so it is only
+// erased by purgeDroppedMessages at the next unlock.
+func dropMessage(c *catshadow.Client, event *catshadow.MessageReceivedEvent) {
+	b, _ := c.GetBlob(droppedPrefix + event.Nickname)
+	b = binary.BigEndian.AppendUint64(b, uint64(event.Timestamp.UnixNano()))
+	c.AddBlob(droppedPrefix+event.Nickname, b)
+}
+
+// droppedAt returns true if the drops recorded in b include a message
+// received at t
+func droppedAt(b []byte, t time.Time) bool {
+	for ; len(b) >= 8; b = b[8:] {
+		d := t.Sub(time.Unix(0, int64(binary.BigEndian.Uint64(b))))
+		if d > -droppedPrecision && d < droppedPrecision {
+			return true
+		}
+	}
+	return false
+}
+
+// isDropped returns true if the message was received from the contact while
+// it was blocked
+func isDropped(c *catshadow.Client, nickname string, m *catshadow.Message) bool {
+	if m == nil || m.Outbound {
+		return false
+	}
+	b, err := c.GetBlob(droppedPrefix + nickname)
+	return err == nil && droppedAt(b, m.Timestamp)
+}
+
+// purgeDroppedMessages erases the dropped messages from a state that was
+// just loaded, before a client shares it, and forgets the drops
+func purgeDroppedMessages(state *catshadow.State) {
+	for id, b := range state.Blob {
+		if !strings.HasPrefix(id, droppedPrefix) {
+			continue
+		}
+		nickname := strings.TrimPrefix(id, droppedPrefix)
+		for msgID, m := range state.Conversations[nickname] {
+			if !m.Outbound && droppedAt(b, m.Timestamp) {
+				utils.ExplicitBzero(m.Plaintext)
+				delete(state.Conversations[nickname], msgID)
+			}
+		}
+		delete(state.Blob, id)
+	}
+}
+
+// lastVisibleMessage returns the last message with the contact that was not
+// dropped
+func lastVisibleMessage(c *catshadow.Client, contact *catshadow.Contact) *catshadow.Message {
+	if !isDropped(c, contact.Nickname, contact.LastMessage) {
+		return contact.LastMessage
+	}
+	messages := visibleMessages(c, contact.Nickname, c.GetSortedConversation(contact.Nickname))
+	if len(messages) == 0 {
+		return nil
+	}
+	return messages[len(messages)-1]
+}
+
+// visibleMessages returns the messages of a conversation that were not dropped
+func visibleMessages(c *catshadow.Client, nickname string, messages catshadow.Messages) catshadow.Messages {
+	visible := make(catshadow.Messages, 0, len(messages))
+	for _, m := range messages {
+		if !isDropped(c, nickname, m) {
+			visible = append(visible, m)
+		}
+	}
+	return visible
+}
+
+// setFlag sets or clears the flag for the contact
+func setFlag(c *catshadow.Client, flag, nickname string, value bool) {
+	if value {
+		c.AddBlob(flag+nickname, []byte{1})
+	} else if hasFlag(c, flag, nickname) {
+		c.DeleteBlob(flag + nickname)
+	}
+}
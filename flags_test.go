@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+// drops records the receive times as dropMessage does
+func drops(times ...time.Time) []byte {
+	var b []byte
+	for _, t := range times {
+		b = binary.BigEndian.AppendUint64(b, uint64(t.UnixNano()))
+	}
+	return b
+}
+
+func TestDroppedAt(t *testing.T) {
+	received := time.Date(2026, 10, 19, 12, 0, 0, 123456789, time.UTC)
+	b := drops(received.Add(-time.Hour), received)
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{received, true},
+		{received.Add(-time.Hour), true},
+		// the precision kept by the statefile
+		{received.Add(time.Microsecond), true},
+		// another message received in the same second
+		{received.Add(2 * time.Millisecond), false},
+		{received.Add(-2 * time.Millisecond), false},
+		{received.Add(time.Second), false},
+	}
+	for _, test := range tests {
+		if got := droppedAt(b, test.t); got != test.want {
+			t.Errorf("droppedAt(%v) = %v, want %v", test.t, got, test.want)
+		}
+	}
+	if droppedAt(nil, received) {
+		t.Error("a message was dropped without a recorded drop")
+	}
+}
+
+func TestPurgeDroppedMessages(t *testing.T) {
+	received := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	dropped := &catshadow.Message{Plaintext: []byte("spam"), Timestamp: received}
+	kept := &catshadow.Message{Plaintext: []byte("hello"), Timestamp: received.Add(time.Millisecond)}
+	sent := &catshadow.Message{Plaintext: []byte("reply"), Timestamp: received, Outbound: true}
+	state := &catshadow.State{
+		Blob: map[string][]byte{droppedPrefix + testContact: drops(received)},
+		Conversations: map[string]map[catshadow.MessageID]*catshadow.Message{
+			testContact: {{1}: dropped, {2}: kept, {3}: sent},
+		},
+	}
+	purgeDroppedMessages(state)
+
+	conversation := state.Conversations[testContact]
+	if _, ok := conversation[catshadow.MessageID{1}]; ok {
+		t.Error("the dropped message was kept")
+	}
+	if string(dropped.Plaintext) != "\x00\x00\x00\x00" {
+		t.Errorf("the dropped message was not erased: %q", dropped.Plaintext)
+	}
+	if len(conversation) != 2 {
+		t.Errorf("%d messages were kept, want 2", len(conversation))
+	}
+	if _, ok := state.Blob[droppedPrefix+testContact]; ok {
+		t.Error("the drops were not forgotten")
+	}
+}
@@ -9,10 +9,13 @@ replace gioui.org/cmd => github.com/mixmasala/gio-cmd v0.0.0-20220915145538-a9fe
 require (
 	gioui.org v0.0.0-20220628163331-e21c665e70ae
 	gioui.org/x/notify v0.0.0-20211102210401-cead9283b8ff
+	github.com/awnumar/memguard v0.22.3
 	github.com/benc-uk/gofract v0.0.0-20211012214247-47caccaf3aac
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/hako/durafmt v0.0.0-20210608085754-5c1018a4e16b
 	github.com/katzenpost/katzenpost v0.0.20
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/crypto v0.4.0
 	golang.org/x/exp/shiny v0.0.0-20220827204233-334a2380cb91
 	golang.org/x/image v0.0.0-20220722155232-062f8c9fd539
 )
@@ -24,12 +27,10 @@ require (
 	git.wow.st/gmp/jni v0.0.0-20210610011705-34026c7e22d0 // indirect
 	github.com/BurntSushi/toml v1.2.1 // indirect
 	github.com/awnumar/memcall v0.1.2 // indirect
-	github.com/awnumar/memguard v0.22.3 // indirect
 	github.com/benoitkugler/textlayout v0.1.3 // indirect
 	github.com/cloudflare/circl v1.3.1 // indirect
 	github.com/eapache/queue v1.1.0 // indirect
 	github.com/esiqveland/notify v0.11.0 // indirect
-	github.com/fxamacker/cbor/v2 v2.4.0 // indirect
 	github.com/gioui/uax v0.2.1-0.20220819135011-cda973fac06d // indirect
 	github.com/go-text/typesetting v0.0.0-20220411150340-35994bc27a7b // indirect
 	github.com/go-toast/toast v0.0.0-20190211030409-01e6764cf0a4 // indirect
@@ -50,7 +51,6 @@ require (
 	gitlab.com/yawning/bsaes.git v0.0.0-20190805113838-0a714cd429ec // indirect
 	gitlab.com/yawning/slice.git v0.0.0-20190714152416-bc4ae2510529 // indirect
 	go.etcd.io/bbolt v1.3.6 // indirect
-	golang.org/x/crypto v0.4.0 // indirect
 	golang.org/x/net v0.4.0 // indirect
 	golang.org/x/sys v0.3.0 // indirect
 	golang.org/x/text v0.5.0 // indirect
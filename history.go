@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+const (
+	historyDateFormat = "2006-01-02"
+	historyTimeFormat = "2006-01-02 15:04"
+)
+
+// historyFileName returns the default file name of the history of a
+// conversation, with the characters that separate paths replaced
+func historyFileName(nickname string) string {
+	nickname = strings.NewReplacer("/", "_", `\`, "_", ":", "_").Replace(nickname)
+	return fmt.Sprintf("katzen-history-%s-%s.txt", nickname, time.Now().Format(historyDateFormat))
+}
+
+// exportHistory writes the conversation with nickname to path as plain text,
+// one message per line, and returns the number of messages written. Dropped
+// messages are left out.
+func exportHistory(c *catshadow.Client, nickname, path string) (int, error) {
+	messages := visibleMessages(c, nickname, c.GetSortedConversation(nickname))
+	var b strings.Builder
+	for _, m := range messages {
+		sender := nickname
+		if m.Outbound {
+			sender = "You"
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", m.Timestamp.Local().Format(historyTimeFormat), sender, m.Plaintext)
+	}
+	return len(messages), os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// ExportHistoryPage writes the history of a conversation to a file
+type ExportHistoryPage struct {
+	a        *App
+	nickname string
+	back     *widget.Clickable
+	export   *widget.Clickable
+	path     *widget.Editor
+	status   string
+}
+
+// ExportHistory is the event that requests the history export page of a contact
+type ExportHistory struct {
+	nickname string
+}
+
+// Layout returns the file field and a warning about the plain text file
+func (p *ExportHistoryPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Export History with "+p.nickname).Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(th, "The messages of this conversation are written to the file as plain text. Anyone who can read the file can read them, even after they expire here, and a panic wipe does not remove it.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.path, "File").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Button(th, p.export, "Export").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+				})
+			}),
+		)
+	})
+}
+
+// Event handles the export button
+func (p *ExportHistoryPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.export.Clicked() {
+		n, err := exportHistory(p.a.c, p.nickname, p.path.Text())
+		if err != nil {
+			p.status = "Export failed: " + err.Error()
+		} else {
+			p.status = fmt.Sprintf("Exported %d messages to %s", n, p.path.Text())
+		}
+		return RedrawEvent{}
+	}
+	return nil
+}
+
+func (p *ExportHistoryPage) Start(stop <-chan struct{}) {
+}
+
+func newExportHistoryPage(a *App, nickname string) *ExportHistoryPage {
+	p := &ExportHistoryPage{a: a,
+		nickname: nickname,
+		back:     &widget.Clickable{},
+		export:   &widget.Clickable{},
+		path:     &widget.Editor{SingleLine: true},
+	}
+	if dir, err := os.UserHomeDir(); err == nil {
+		p.path.SetText(filepath.Join(dir, historyFileName(nickname)))
+	}
+	return p
+}
@@ -3,8 +3,9 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
-	"gioui.org/gesture"
+	"fmt"
 	"gioui.org/io/key"
+	"gioui.org/io/semantic"
 	"gioui.org/layout"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
@@ -25,63 +26,91 @@ import (
 var (
 	contactList       = &layout.List{Axis: layout.Vertical, ScrollToEnd: false}
 	selectedIdx       = 0
+	showArchived      = false
 	kb                = false
 	connectIcon, _    = widget.NewIcon(icons.DeviceSignalWiFi4Bar)
 	disconnectIcon, _ = widget.NewIcon(icons.DeviceSignalWiFiOff)
 	settingsIcon, _   = widget.NewIcon(icons.ActionSettings)
 	addContactIcon, _ = widget.NewIcon(icons.SocialPersonAdd)
+	pendingIcon, _    = widget.NewIcon(icons.ActionHourglassEmpty)
 	logo              = getLogo()
 	units, _          = durafmt.UnitsCoder{PluralSep: ":", UnitsSep: ","}.Decode("y:y,w:w,d:d,h:h,m:m,s:s,ms:ms,us:us")
 	avatars           = make(map[string]layout.Widget)
-	shortcuts         = key.Set(key.NameUpArrow + "|" +
-		key.NameDownArrow + "|" +
-		key.NamePageUp + "|" +
-		key.NamePageDown + "|" +
-		key.NameReturn + "|" +
-		key.NameEscape + "|" +
-		key.NameF1 + "|" + // show help page (not implemented)
-		key.NameF2 + "|" + // add contact
-		key.NameF3 + "|" + // show client settings
-		key.NameF4 + "|" + // toggle connection status
-		key.NameF5) // edit contact
 )
 
 type HomePage struct {
-	a             *App
-	addContact    *widget.Clickable
-	connect       *widget.Clickable
-	showSettings  *widget.Clickable
-	av            map[string]*widget.Image
-	contactClicks map[string]*gesture.Click
+	a              *App
+	addContact     *widget.Clickable
+	connect        *widget.Clickable
+	showSettings   *widget.Clickable
+	showPending    *widget.Clickable
+	lock           *widget.Clickable
+	search         *widget.Editor
+	sortMode       *widget.Clickable
+	filterPending  *widget.Clickable
+	filterVerify   *widget.Clickable
+	filterFavorite *widget.Clickable
+	filterMuted    *widget.Clickable
+	archivedToggle *widget.Clickable
+	menuFor        string
+	menuPin        *widget.Clickable
+	menuArchive    *widget.Clickable
+	menuFavorite   *widget.Clickable
+	menuClose      *widget.Clickable
+	av             map[string]*widget.Image
+	contactClicks  map[string]*widget.Clickable
+	contactPress   map[string]*LongPress
+	previews       messageTexts
+	// previewed is the last message shown for each contact
+	previewed map[string]*catshadow.Message
+	// longPressed suppresses the click that ends a long press
+	longPressed string
+	// conversation is shown beside the contact list when the window is wide
+	conversation *conversationPage
+	convStop     chan struct{}
+	wide         bool
+	// stop is closed when the home page is stopped, which stops the conversation too
+	stop <-chan struct{}
 }
 
 type AddContactClick struct{}
 type ShowSettingsClick struct{}
 
-func (p *HomePage) Layout(gtx layout.Context) layout.Dimensions {
-	contacts := getSortedContacts(p.a)
+// layoutContacts returns the contact list with its topbar, search field and filters
+func (p *HomePage) layoutContacts(gtx layout.Context) layout.Dimensions {
+	contacts, archived := getFilteredContacts(p.a, homeFilter)
 	// xxx do not request this every frame...
 	bg := Background{
 		Color: th.Bg,
 		Inset: layout.Inset{},
 	}
 
-	if len(contacts) == 0 {
+	// keyboard selection moves through the archived contacts when they are shown
+	selectable := len(contacts)
+	if showArchived {
+		selectable = selectable + len(archived)
+	}
+	if selectable == 0 {
 		selectedIdx = 0
 	} else if selectedIdx < 0 {
-		selectedIdx = len(contacts) - 1
+		selectedIdx = selectable - 1
 	} else {
-		selectedIdx = selectedIdx % len(contacts)
+		selectedIdx = selectedIdx % selectable
 	}
 
 	// re-center list view for keyboard contact selection
 	if kb {
-		if selectedIdx < contactList.Position.First || selectedIdx >= contactList.Position.First+contactList.Position.Count {
+		// skip over the archived section header
+		listIdx := selectedIdx
+		if listIdx >= len(contacts) {
+			listIdx = listIdx + 1
+		}
+		if listIdx < contactList.Position.First || listIdx >= contactList.Position.First+contactList.Position.Count {
 			// list doesn't wrap around view to end, so do not give negative value for First
-			if selectedIdx < contactList.Position.Count-1 {
+			if listIdx < contactList.Position.Count-1 {
 				contactList.Position.First = 0
 			} else {
-				contactList.Position.First = (selectedIdx - contactList.Position.Count + 1)
+				contactList.Position.First = (listIdx - contactList.Position.Count + 1)
 			}
 		}
 	}
@@ -97,102 +126,285 @@ func (p *HomePage) Layout(gtx layout.Context) layout.Dimensions {
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					func() layout.FlexChild {
 						if isConnected {
-							return layout.Rigid(button(th, p.connect, connectIcon).Layout)
+							return layout.Rigid(button(th, p.connect, connectIcon, "Disconnect").Layout)
+						}
+						return layout.Rigid(button(th, p.connect, disconnectIcon, "Connect").Layout)
+					}(),
+					func() layout.FlexChild {
+						if hasPendingContacts(p.a) {
+							return layout.Rigid(button(th, p.showPending, pendingIcon, "Pending key exchanges").Layout)
 						}
-						return layout.Rigid(button(th, p.connect, disconnectIcon).Layout)
+						return layout.Rigid(func(gtx C) D { return layout.Dimensions{} })
 					}(),
-					layout.Rigid(button(th, p.showSettings, settingsIcon).Layout),
-					layout.Rigid(button(th, p.addContact, addContactIcon).Layout),
+					layout.Rigid(button(th, p.lock, lockIcon, "Lock").Layout),
+					layout.Rigid(button(th, p.showSettings, settingsIcon, "Settings").Layout),
+					layout.Rigid(button(th, p.addContact, addContactIcon, "Add contact").Layout),
 				)
 			}),
 
+			// search field and filter chips
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Editor(th, p.search, "Search").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+						layout.Rigid(func(gtx C) D {
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+								layout.Rigid(chip(th, p.sortMode, "Sort: "+homeFilter.sort.String(), homeFilter.sort != sortRecent)),
+								layout.Rigid(chip(th, p.filterPending, "Pending", homeFilter.pending)),
+								layout.Rigid(chip(th, p.filterVerify, "Verified", homeFilter.verified)),
+								layout.Rigid(chip(th, p.filterFavorite, "Favorites", homeFilter.favorite)),
+								layout.Rigid(chip(th, p.filterMuted, "Muted", homeFilter.muted)),
+							)
+						}),
+					)
+				})
+			}),
+
 			// show list of conversations
 			layout.Flexed(1, func(gtx C) D {
+				if len(contacts) == 0 && len(archived) == 0 && homeFilter.active() {
+					return layout.Center.Layout(gtx, material.Body2(th, "No matching contacts").Layout)
+				}
 				gtx.Constraints.Min.X = gtx.Dp(unit.Dp(300))
-				// the contactList
-				return contactList.Layout(gtx, len(contacts), func(gtx C, i int) layout.Dimensions {
-					lastMsg := contacts[i].LastMessage
-
-					// inset each contact Flex
-					in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
-
-					// if the layout is selected, change background color
-					bg := Background{Inset: in}
-					if kb && i == selectedIdx {
-						bg.Color = th.ContrastBg
-					} else {
-						bg.Color = th.Bg
+				// the contactList, followed by the archived section header and the archived contacts
+				n := len(contacts)
+				if len(archived) > 0 {
+					n = n + 1
+					if showArchived {
+						n = n + len(archived)
 					}
-
-					return bg.Layout(gtx, func(gtx C) D {
-						// returns Flex of contact icon, contact name, and last message received or sent
-						if _, ok := p.contactClicks[contacts[i].Nickname]; !ok {
-							c := new(gesture.Click)
-							p.contactClicks[contacts[i].Nickname] = c
-						}
-
-						dims := layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceEvenly}.Layout(gtx,
-							// contact avatar
-							layout.Rigid(func(gtx C) D {
-								return layoutAvatar(gtx, p.a.c, contacts[i].Nickname)
-							}),
-							// contact name and last message
-							layout.Flexed(1, func(gtx C) D {
-								gtx.Constraints.Max.Y = gtx.Dp(unit.Dp(96))
-								return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start, Spacing: layout.SpaceBetween}.Layout(gtx,
-									layout.Rigid(func(gtx C) D {
-										return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Start, Spacing: layout.SpaceBetween}.Layout(gtx,
-											// contact name
-											layout.Rigid(func(gtx C) D {
-												in := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(12), Right: unit.Dp(12)}
-												return in.Layout(gtx, ContactStyle(th, contacts[i].Nickname).Layout)
-											}),
-											layout.Rigid(func(gtx C) D {
-												return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start, Spacing: layout.SpaceEnd}.Layout(gtx,
-												layout.Rigid(func(gtx C) D {
-													if contacts[i].IsPending {
-														return pandaIcon.Layout(gtx, th.Palette.ContrastBg)
-													}
-													return fill{th.Bg}.Layout(gtx)
-												}),
-												layout.Rigid(func(gtx C) D {
-													// timestamp
-													if lastMsg != nil {
-														messageAge := strings.Replace(durafmt.ParseShort(time.Now().Round(0).Sub(lastMsg.Timestamp).Truncate(time.Minute)).Format(units), "0 s", "now", 1)
-														return material.Caption(th, messageAge).Layout(gtx)
-													}
-													return fill{th.Bg}.Layout(gtx)
-												}),
-											)}),
-										)
-									}),
-									// last message
-									layout.Rigid(func(gtx C) D {
-										in := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(12), Right: unit.Dp(12)}
-										if lastMsg != nil {
-											return in.Layout(gtx, func(gtx C) D {
-												// TODO: set the color based on sent or received
-												return material.Body2(th, string(lastMsg.Plaintext)).Layout(gtx)
-											})
-										} else {
-											return fill{th.Bg}.Layout(gtx)
-										}
-									}),
-								)
-							}),
-						)
-						a := clip.Rect(image.Rectangle{Max: dims.Size})
-						t := a.Push(gtx.Ops)
-						p.contactClicks[contacts[i].Nickname].Add(gtx.Ops)
-						t.Pop()
-						return dims
-					})
+				}
+				return contactList.Layout(gtx, n, func(gtx C, i int) layout.Dimensions {
+					if i < len(contacts) {
+						return p.layoutContact(gtx, contacts[i], kb && i == selectedIdx)
+					}
+					if i == len(contacts) {
+						return p.layoutArchivedHeader(gtx, len(archived))
+					}
+					return p.layoutContact(gtx, archived[i-len(contacts)-1], kb && i-1 == selectedIdx)
 				})
 			}),
 		)
 	})
 }
 
+// layoutArchivedHeader returns the header of the collapsible archived section
+func (p *HomePage) layoutArchivedHeader(gtx C, count int) D {
+	icon := expandIcon
+	if showArchived {
+		icon = collapseIcon
+	}
+	in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+	return material.Clickable(gtx, p.archivedToggle, func(gtx C) D {
+		// the icon shows whether the section is expanded
+		if showArchived {
+			semantic.DescriptionOp("Hide archived contacts").Add(gtx.Ops)
+		} else {
+			semantic.DescriptionOp("Show archived contacts").Add(gtx.Ops)
+		}
+		return in.Layout(gtx, func(gtx C) D {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+					return archiveIcon.Layout(gtx, th.Fg)
+				}),
+				layout.Rigid(func(gtx C) D {
+					in := layout.Inset{Left: unit.Dp(12), Right: unit.Dp(12)}
+					return in.Layout(gtx, material.Body1(th, fmt.Sprintf("Archived (%d)", count)).Layout)
+				}),
+				layout.Flexed(1, fill{th.Bg}.Layout),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+					return icon.Layout(gtx, th.Fg)
+				}),
+			)
+		})
+	})
+}
+
+// layoutContact returns a row of the contact list, followed by the contact
+// menu if the row was long-pressed
+func (p *HomePage) layoutContact(gtx C, contact *catshadow.Contact, selected bool) D {
+	lastMsg := lastVisibleMessage(p.a.c, contact)
+	nickname := contact.Nickname
+	if old := p.previewed[nickname]; old != lastMsg {
+		// the text of the replaced message is no longer needed
+		delete(p.previews, old)
+		p.previewed[nickname] = lastMsg
+	}
+
+	// inset each contact Flex
+	in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+
+	// if the layout is selected or open in the conversation pane, change background color
+	bg := Background{Inset: in}
+	if selected || p.isOpen(nickname) {
+		bg.Color = th.ContrastBg
+	} else {
+		bg.Color = th.Bg
+	}
+
+	if _, ok := p.contactClicks[nickname]; !ok {
+		p.contactClicks[nickname] = &widget.Clickable{}
+		p.contactPress[nickname] = NewLongPress(p.a.w.Invalidate, 800*time.Millisecond)
+	}
+	content := func(gtx C) D {
+		return bg.Layout(gtx, func(gtx C) D {
+			// returns Flex of contact icon, contact name, and last message received or sent
+			dims := layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceEvenly}.Layout(gtx,
+				// contact avatar
+				layout.Rigid(func(gtx C) D {
+					return layoutAvatar(gtx, p.a.c, nickname)
+				}),
+				// contact name and last message
+				layout.Flexed(1, func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Dp(unit.Dp(96))
+					return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start, Spacing: layout.SpaceBetween}.Layout(gtx,
+						layout.Rigid(func(gtx C) D {
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Start, Spacing: layout.SpaceBetween}.Layout(gtx,
+								// contact name
+								layout.Rigid(func(gtx C) D {
+									in := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(12), Right: unit.Dp(12)}
+									return in.Layout(gtx, ContactStyle(th, nickname).Layout)
+								}),
+								// verified, pinned and favorite badges
+								layout.Rigid(func(gtx C) D {
+									if isVerified(p.a.c, nickname) {
+										gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+										return verifiedIcon.Layout(gtx, th.Palette.ContrastFg)
+									}
+									return layout.Dimensions{}
+								}),
+								layout.Rigid(func(gtx C) D {
+									if hasFlag(p.a.c, pinnedFlag, nickname) {
+										gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+										return pinIcon.Layout(gtx, th.Palette.ContrastFg)
+									}
+									return layout.Dimensions{}
+								}),
+								layout.Rigid(func(gtx C) D {
+									if hasFlag(p.a.c, favoriteFlag, nickname) {
+										gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+										return favoriteIcon.Layout(gtx, th.Palette.ContrastFg)
+									}
+									return layout.Dimensions{}
+								}),
+								layout.Rigid(func(gtx C) D {
+									if isBlocked(p.a.c, nickname) {
+										gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+										return blockedIcon.Layout(gtx, th.Palette.ContrastFg)
+									}
+									return layout.Dimensions{}
+								}),
+								layout.Rigid(func(gtx C) D {
+									if isMuted(p.a.c, nickname) {
+										gtx.Constraints.Min.X = gtx.Sp(th.TextSize)
+										return mutedIcon.Layout(gtx, th.Palette.ContrastFg)
+									}
+									return layout.Dimensions{}
+								}),
+								layout.Flexed(1, fill{th.Bg}.Layout),
+								layout.Rigid(func(gtx C) D {
+									return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start, Spacing: layout.SpaceEnd}.Layout(gtx,
+										layout.Rigid(func(gtx C) D {
+											if contact.IsPending {
+												return pandaIcon.Layout(gtx, th.Palette.ContrastBg)
+											}
+											if isUnread(p.a.c, contact) && !isMuted(p.a.c, nickname) {
+												return layoutUnreadBadge(gtx)
+											}
+											return fill{th.Bg}.Layout(gtx)
+										}),
+										layout.Rigid(func(gtx C) D {
+											// timestamp
+											if lastMsg != nil {
+												messageAge := strings.Replace(durafmt.ParseShort(time.Now().Round(0).Sub(lastMsg.Timestamp).Truncate(time.Minute)).Format(units), "0 s", "now", 1)
+												return material.Caption(th, messageAge).Layout(gtx)
+											}
+											return fill{th.Bg}.Layout(gtx)
+										}),
+									)
+								}),
+							)
+						}),
+						// last message
+						layout.Rigid(func(gtx C) D {
+							in := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(12), Right: unit.Dp(12)}
+							if lastMsg != nil {
+								return in.Layout(gtx, func(gtx C) D {
+									// TODO: set the color based on sent or received
+									return material.Body2(th, p.previews.text(lastMsg)).Layout(gtx)
+								})
+							} else {
+								return fill{th.Bg}.Layout(gtx)
+							}
+						}),
+					)
+				}),
+			)
+			a := clip.Rect(image.Rectangle{Max: dims.Size})
+			t := a.Push(gtx.Ops)
+			p.contactPress[nickname].Add(gtx.Ops)
+			t.Pop()
+			return dims
+		})
+	}
+	// the row is announced with the state that its badges show
+	row := func(gtx C) D {
+		return control(gtx, p.contactClicks[nickname], contactDescription(p.a.c, contact), func(gtx C) D {
+			semantic.SelectedOp(selected || p.isOpen(nickname)).Add(gtx.Ops)
+			return content(gtx)
+		})
+	}
+	if p.menuFor != nickname {
+		return row(gtx)
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(row),
+		layout.Rigid(func(gtx C) D {
+			pinLabel, archiveLabel, favoriteLabel := "Pin", "Archive", "Favorite"
+			if hasFlag(p.a.c, pinnedFlag, nickname) {
+				pinLabel = "Unpin"
+			}
+			if hasFlag(p.a.c, archivedFlag, nickname) {
+				archiveLabel = "Unarchive"
+			}
+			if hasFlag(p.a.c, favoriteFlag, nickname) {
+				favoriteLabel = "Unfavorite"
+			}
+			in := layout.Inset{Bottom: unit.Dp(8), Left: unit.Dp(66), Right: unit.Dp(12)}
+			return in.Layout(gtx, func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(chip(th, p.menuPin, pinLabel, false)),
+					layout.Rigid(chip(th, p.menuArchive, archiveLabel, false)),
+					layout.Rigid(chip(th, p.menuFavorite, favoriteLabel, false)),
+					layout.Rigid(chip(th, p.menuClose, "Close", true)),
+				)
+			})
+		}),
+	)
+}
+
+// layoutUnreadBadge returns a dot marking a conversation with unread messages
+func layoutUnreadBadge(gtx C) D {
+	sz := gtx.Dp(unit.Dp(10))
+	defer clip.Ellipse(image.Rectangle{Max: image.Point{X: sz, Y: sz}}).Push(gtx.Ops).Pop()
+	paint.ColorOp{Color: th.ContrastBg}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	return layout.Dimensions{Size: image.Point{X: sz, Y: sz}}
+}
+
+// selectableContacts returns the contacts that keyboard selection moves through
+func (p *HomePage) selectableContacts() sortedContacts {
+	contacts, archived := getFilteredContacts(p.a, homeFilter)
+	if showArchived {
+		contacts = append(contacts, archived...)
+	}
+	return contacts
+}
+
 func getLogo() *widget.Image {
 	d, err := base64.StdEncoding.DecodeString(assets.Logob64)
 	if err != nil {
@@ -260,8 +472,8 @@ type OfflineClick struct {
 	Err error
 }
 
-// Event returns a ChooseContactClick event when a contact is chosen
-func (p *HomePage) Event(gtx layout.Context) interface{} {
+// contactsEvent returns a ChooseContactClick event when a contact is chosen
+func (p *HomePage) contactsEvent(gtx layout.Context) interface{} {
 	if p.connect.Clicked() {
 		if !isConnected && !isConnecting {
 			return OnlineClick{}
@@ -275,46 +487,115 @@ func (p *HomePage) Event(gtx layout.Context) interface{} {
 	if p.showSettings.Clicked() {
 		return ShowSettingsClick{}
 	}
+	if p.showPending.Clicked() {
+		return ShowPendingClick{}
+	}
+	if p.lock.Clicked() {
+		return LockClick{}
+	}
+	for _, e := range p.search.Events() {
+		switch e.(type) {
+		case widget.ChangeEvent:
+			homeFilter.query = p.search.Text()
+			selectedIdx = 0
+		case widget.SubmitEvent:
+			// open the first match
+			if contacts := p.selectableContacts(); len(contacts) > 0 {
+				return ChooseContactClick{nickname: contacts[0].Nickname}
+			}
+		}
+	}
+	if p.sortMode.Clicked() {
+		homeFilter.sort = (homeFilter.sort + 1) % numSortModes
+		selectedIdx = 0
+	}
+	if p.filterPending.Clicked() {
+		homeFilter.pending = !homeFilter.pending
+		selectedIdx = 0
+	}
+	if p.filterVerify.Clicked() {
+		homeFilter.verified = !homeFilter.verified
+		selectedIdx = 0
+	}
+	if p.filterFavorite.Clicked() {
+		homeFilter.favorite = !homeFilter.favorite
+		selectedIdx = 0
+	}
+	if p.filterMuted.Clicked() {
+		homeFilter.muted = !homeFilter.muted
+		selectedIdx = 0
+	}
+	if p.archivedToggle.Clicked() {
+		showArchived = !showArchived
+	}
+	if p.menuPin.Clicked() {
+		setFlag(p.a.c, pinnedFlag, p.menuFor, !hasFlag(p.a.c, pinnedFlag, p.menuFor))
+		p.menuFor = ""
+	}
+	if p.menuArchive.Clicked() {
+		setFlag(p.a.c, archivedFlag, p.menuFor, !hasFlag(p.a.c, archivedFlag, p.menuFor))
+		p.menuFor = ""
+	}
+	if p.menuFavorite.Clicked() {
+		setFlag(p.a.c, favoriteFlag, p.menuFor, !hasFlag(p.a.c, favoriteFlag, p.menuFor))
+		p.menuFor = ""
+	}
+	if p.menuClose.Clicked() {
+		p.menuFor = ""
+	}
+	for nickname, press := range p.contactPress {
+		for _, e := range press.Events(gtx.Queue) {
+			if e.Type == LongPressed {
+				p.menuFor = nickname
+				p.longPressed = nickname
+				return RedrawEvent{}
+			}
+		}
+	}
 	for nickname, click := range p.contactClicks {
-		for _, e := range click.Events(gtx.Queue) {
-			if e.Type == gesture.TypeClick {
-				return ChooseContactClick{nickname: nickname}
+		if click.Clicked() {
+			if p.longPressed == nickname {
+				p.longPressed = ""
+				continue
 			}
+			return ChooseContactClick{nickname: nickname}
 		}
 	}
 	// check for keypress events
-	key.InputOp{Tag: p, Keys: shortcuts}.Add(gtx.Ops)
+	key.InputOp{Tag: p, Keys: keymap.set(scopeHome)}.Add(gtx.Ops)
 	for _, e := range gtx.Events(p) {
-		switch e := e.(type) {
-		case key.Event:
-			if e.Name == key.NameF1 && e.State == key.Release {
-			}
-			if e.Name == key.NameF2 && e.State == key.Release {
-				return AddContactClick{}
-			}
-			if e.Name == key.NameF3 && e.State == key.Release {
-				return ShowSettingsClick{}
-			}
-			if e.Name == key.NameF4 && e.State == key.Release {
-				if !isConnected {
-					return OnlineClick{}
-				}
-				return OfflineClick{}
-			}
-			if e.Name == key.NameUpArrow && e.State == key.Release {
-				kb = true
-				selectedIdx = selectedIdx - 1
-			}
-			if e.Name == key.NameDownArrow && e.State == key.Release {
-				kb = true
-				selectedIdx = selectedIdx + 1
+		e, ok := e.(key.Event)
+		if !ok {
+			continue
+		}
+		switch keymap.action(scopeHome, e) {
+		case actionAddContact:
+			return AddContactClick{}
+		case actionSettings:
+			return ShowSettingsClick{}
+		case actionConnect:
+			if !isConnected {
+				return OnlineClick{}
 			}
-			if e.Name == key.NameEscape && e.State == key.Release {
-				kb = false
+			return OfflineClick{}
+		case actionSelectPrevious:
+			kb = true
+			selectedIdx = selectedIdx - 1
+		case actionSelectNext:
+			kb = true
+			selectedIdx = selectedIdx + 1
+		case actionSearch:
+			p.search.Focus()
+		case actionBack:
+			if !kb && p.search.Text() != "" {
+				p.search.SetText("")
+				homeFilter.query = ""
 			}
-			if e.Name == key.NameReturn && e.State == key.Release {
-				contacts := getSortedContacts(p.a)
-				kb = false
+			kb = false
+		case actionOpen:
+			contacts := p.selectableContacts()
+			kb = false
+			if selectedIdx < len(contacts) {
 				return ChooseContactClick{nickname: contacts[selectedIdx].Nickname}
 			}
 		}
@@ -324,17 +605,36 @@ func (p *HomePage) Event(gtx layout.Context) interface{} {
 }
 
 func (p *HomePage) Start(stop <-chan struct{}) {
+	p.stop = stop
 }
 
 func newHomePage(a *App) *HomePage {
-	return &HomePage{
-		a:             a,
-		addContact:    &widget.Clickable{},
-		connect:       &widget.Clickable{},
-		showSettings:  &widget.Clickable{},
-		contactClicks: make(map[string]*gesture.Click),
-		av:            make(map[string]*widget.Image),
+	p := &HomePage{
+		a:              a,
+		addContact:     &widget.Clickable{},
+		connect:        &widget.Clickable{},
+		showSettings:   &widget.Clickable{},
+		showPending:    &widget.Clickable{},
+		lock:           &widget.Clickable{},
+		search:         &widget.Editor{SingleLine: true, Submit: true},
+		sortMode:       &widget.Clickable{},
+		filterPending:  &widget.Clickable{},
+		filterVerify:   &widget.Clickable{},
+		filterFavorite: &widget.Clickable{},
+		filterMuted:    &widget.Clickable{},
+		archivedToggle: &widget.Clickable{},
+		menuPin:        &widget.Clickable{},
+		menuArchive:    &widget.Clickable{},
+		menuFavorite:   &widget.Clickable{},
+		menuClose:      &widget.Clickable{},
+		contactClicks:  make(map[string]*widget.Clickable),
+		contactPress:   make(map[string]*LongPress),
+		av:             make(map[string]*widget.Image),
+		previews:       make(messageTexts),
+		previewed:      make(map[string]*catshadow.Message),
 	}
+	p.search.SetText(homeFilter.query)
+	return p
 }
 
 func ContactStyle(th *material.Theme, txt string) material.LabelStyle {
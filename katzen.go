@@ -8,6 +8,7 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/awnumar/memguard"
 	"github.com/katzenpost/katzenpost/catshadow"
 	"github.com/katzenpost/katzenpost/client"
 	"time"
@@ -16,13 +17,13 @@ import (
 	_ "gioui.org/app/permission/foreground"
 	_ "gioui.org/app/permission/storage"
 	"gioui.org/font/gofont"
+	"gioui.org/io/clipboard"
 	"gioui.org/io/key"
 	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/unit"
 	"gioui.org/widget/material"
-	"gioui.org/x/notify"
 	"net/http"
 	_ "net/http/pprof"
 )
@@ -37,10 +38,12 @@ var (
 	clientConfigFile = flag.String("f", "", "Path to the client config file.")
 	stateFile        = flag.String("s", "catshadow_statefile", "Path to the client state file.")
 	debug            = flag.Bool("d", false, "Enable golang debug service.")
+	wipeKeys         = flag.Bool("w", false, "Enable the panic wipe key chord (Ctrl+Shift+Delete) on the sign in page.")
 
 	minPasswordLen = 5 // XXX pick something reasonable
 
-	notifications = make(map[string]notify.Notification)
+	// notifications applies the notification policy
+	notifications *notifier
 
 	//go:embed default_config_without_tor.toml
 	cfgWithoutTor []byte
@@ -50,10 +53,7 @@ var (
 	// theme
 	th = func() *material.Theme {
 		th := material.NewTheme(gofont.Collection())
-		th.Bg = rgb(0x0)
-		th.Fg = rgb(0xFFFFFFFF)
-		th.ContrastBg = rgb(0x22222222)
-		th.ContrastFg = rgb(0x77777777)
+		th.Palette = activeTheme.palette()
 		return th
 	}()
 
@@ -69,6 +69,10 @@ type App struct {
 	stack pageStack
 	focus bool
 	stage system.Stage
+	// lastActive is when the user last interacted with katzen
+	lastActive time.Time
+	// wiped is set once all local data has been destroyed
+	wiped bool
 }
 
 func newApp(w *app.Window) *App {
@@ -76,22 +80,42 @@ func newApp(w *app.Window) *App {
 		w:   w,
 		ops: &op.Ops{},
 	}
+	notifications = newNotifier(a)
 	return a
 }
 
+// back pops the current page. Messages received while a conversation was
+// open have been read once it is left.
+func (a *App) back() {
+	if c, ok := a.stack.Current().(*conversationPage); ok {
+		markRead(a.c, c.nickname)
+	}
+	a.stack.Pop()
+}
+
 func (a *App) Layout(gtx layout.Context) {
 	a.update(gtx)
-	a.stack.Current().Layout(gtx)
+	page := a.stack.Current()
+	gtx = display.scale(gtx, page)
+	page.Layout(gtx)
+	keyHelp.Layout(gtx, page)
+	palette.Layout(gtx)
+	a.layoutClipboard(gtx)
 }
 
 func (a *App) update(gtx layout.Context) {
 	page := a.stack.Current()
-	if e := page.Event(gtx); e != nil {
+	e := page.Event(gtx)
+	if e == nil {
+		e = palette.Event(gtx, a)
+	}
+	if e != nil {
+		a.touch()
 		switch e := e.(type) {
 		case RedrawEvent:
 			a.w.Invalidate()
 		case BackEvent:
-			a.stack.Pop()
+			a.back()
 		case signInStarted:
 			p := newUnlockPage(e.result)
 			a.stack.Clear(p)
@@ -99,16 +123,29 @@ func (a *App) update(gtx layout.Context) {
 			isConnected = false
 			isConnecting = false
 			fmt.Printf("unlockError: %s\n", e.err)
-			a.stack.Clear(newSignInPage(a))
+			if errors.Is(e.err, errStatePassphrase) {
+				recordUnlockFailure()
+			}
+			p := newSignInPage(a)
+			p.errMsg = e.err.Error()
+			a.stack.Clear(p)
 		case restartClient:
-			isConnected = false
-			isConnecting = false
 			fmt.Printf("restartClient\n")
-			a.stack.Clear(newSignInPage(a))
+			a.signOut(e.status)
+		case LockClick:
+			a.lock()
 		case unlockSuccess:
 			// validate the statefile somehow
 			a.c = e.client
 			a.c.Start()
+			recordUnlockSuccess()
+			shredReplacedStatefile()
+			a.touch()
+			a.applyTheme(loadTheme(a.c))
+			loadDisplay(a.c)
+			loadKeyBindings(a.c)
+			recordPandaStarts(a.c)
+			a.expirePendingExchanges()
 			a.stack.Clear(newHomePage(a))
 			if _, err := a.c.GetBlob("AutoConnect"); err == nil {
 				a.c.Online()
@@ -138,7 +175,7 @@ func (a *App) update(gtx layout.Context) {
 		case AddContactComplete:
 			a.stack.Pop()
 		case ChooseContactClick:
-			a.stack.Push(newConversationPage(a, e.nickname))
+			a.openConversation(e.nickname)
 		case ChooseAvatar:
 			a.stack.Push(newAvatarPicker(a, e.nickname, ""))
 		case ChooseAvatarPath:
@@ -148,6 +185,36 @@ func (a *App) update(gtx layout.Context) {
 			a.stack.Push(newRenameContactPage(a, e.nickname))
 		case EditContact:
 			a.stack.Push(newEditContactPage(a, e.nickname))
+		case VerifyContact:
+			a.stack.Push(newVerifyContactPage(a, e.nickname))
+		case ContactNotes:
+			a.stack.Push(newContactNotesPage(a, e.nickname))
+		case ExportHistory:
+			a.stack.Push(newExportHistoryPage(a, e.nickname))
+		case ShowAddressBookClick:
+			a.stack.Push(newAddressBookPage(a))
+		case ShowBackupClick:
+			a.stack.Push(newBackupPage(a))
+		case ShowChangePassphraseClick:
+			a.stack.Push(newChangePassphrasePage(a))
+		case ShowWipeClick:
+			a.stack.Push(newWipePage(a))
+		case panicWipe:
+			a.wipe()
+		case ShowDuressClick:
+			a.stack.Push(newDuressPage(a))
+		case ShowKeysClick:
+			a.stack.Push(newKeysPage(a))
+		case ShowProfilesClick:
+			a.stack.Push(newProfilesPage(a))
+		case ProfilesComplete:
+			a.stack.Clear(newSignInPage(a))
+		case ShowRestoreClick:
+			a.stack.Push(newRestorePage(a))
+		case ShowPendingClick:
+			a.stack.Push(newPendingPage(a))
+		case ShowSecret:
+			a.stack.Push(newSecretPage(a, e.nickname))
 		case EditContactComplete:
 			a.stack.Clear(newHomePage(a))
 		case MessageSent:
@@ -168,15 +235,23 @@ func (a *App) run() error {
 	}
 	defer func() {
 		if a.c != nil {
-			a.c.Shutdown()
+			shutdownClient(a.c)
 			a.c.Wait()
 		}
 	}()
 
+	tick := time.NewTicker(1 * time.Minute)
+	defer tick.Stop()
+
 	// select from all event sources
 	for {
+		// a locked app has no client until it is unlocked again
+		var events chan interface{}
+		if a.c != nil {
+			events = a.c.EventSink
+		}
 		select {
-		case e := <-a.c.EventSink:
+		case e := <-events:
 			if err := a.handleCatshadowEvent(e); err != nil {
 				return err
 			}
@@ -184,8 +259,12 @@ func (a *App) run() error {
 			if err := a.handleGioEvents(e); err != nil {
 				return err
 			}
-		case <-time.After(1 * time.Minute):
+		case <-tick.C:
 			// redraw the screen to update the message timestamps once per minute
+			if a.c != nil {
+				a.expirePendingExchanges()
+			}
+			a.lockIfIdle()
 			a.w.Invalidate()
 		}
 	}
@@ -212,12 +291,14 @@ func uiMain() {
 		w := app.NewWindow(
 			app.Size(unit.Dp(400), unit.Dp(400)),
 			app.Title("Katzen"),
-			app.NavigationColor(rgb(0x0)),
-			app.StatusColor(rgb(0x0)),
+			app.NavigationColor(th.Bg),
+			app.StatusColor(th.Bg),
 		)
-		if err := newApp(w).run(); err != nil {
+		if err := newApp(w).run(); err != nil && err != errWiped {
 			fmt.Fprintf(os.Stderr, "Failed: %v\n", err)
 		}
+		// wipe the locked buffers still held
+		memguard.Purge()
 		os.Exit(0)
 	}()
 	app.Main()
@@ -234,63 +315,66 @@ func (a *App) handleCatshadowEvent(e interface{}) error {
 		isConnecting = false
 		if event.IsConnected {
 			isConnected = true
-			go func() {
-				if n, err := notify.Push("Connected", "Katzen has connected"); err == nil {
-					<-time.After(notificationTimeout)
-					n.Cancel()
-				}
-			}()
+			notifications.transient(plainNotice("Connected", "Katzen has connected"))
 		} else {
 			isConnected = false
-			go func() {
-				if n, err := notify.Push("Disconnected", "Katzen has disconnected"); err == nil {
-					<-time.After(notificationTimeout)
-					n.Cancel()
-				}
-			}()
+			notifications.transient(plainNotice("Disconnected", "Katzen has disconnected"))
 		}
 		if event.Err != nil {
-			go func() {
-				if n, err := notify.Push("Error", fmt.Sprintf("Katzen error: %s", event.Err)); err == nil {
-					<-time.After(notificationTimeout)
-					n.Cancel()
-				}
-			}()
+			notifications.transient(notice{title: "Error",
+				full:    fmt.Sprintf("Katzen error: %s", event.Err),
+				sender:  fmt.Sprintf("Katzen error: %s", event.Err),
+				generic: "Katzen error",
+			})
 		}
 	case *catshadow.KeyExchangeCompletedEvent:
 		if event.Err != nil {
-			if n, err := notify.Push("Key Exchange", fmt.Sprintf("Failed: %s", event.Err)); err == nil {
-				go func() { <-time.After(notificationTimeout); n.Cancel() }()
-			}
+			notifications.transient(notice{title: "Key Exchange",
+				full:    fmt.Sprintf("Failed with %s: %s", event.Nickname, event.Err),
+				sender:  fmt.Sprintf("Failed with %s", event.Nickname),
+				generic: "Key exchange failed",
+			})
 		} else {
-			if n, err := notify.Push("Key Exchange", fmt.Sprintf("Completed: %s", event.Nickname)); err == nil {
-				go func() { <-time.After(notificationTimeout); n.Cancel() }()
-			}
+			a.c.DeleteBlob("pending://" + event.Nickname)
+			applyPendingImport(a.c, event.Nickname)
+			notifications.transient(notice{title: "Key Exchange",
+				full:    fmt.Sprintf("Completed: %s", event.Nickname),
+				sender:  fmt.Sprintf("Completed: %s", event.Nickname),
+				generic: "Key exchange completed",
+			})
 		}
 	case *catshadow.MessageNotSentEvent:
-		if n, err := notify.Push("Message Not Sent", fmt.Sprintf("Failed to send message to %s", event.Nickname)); err == nil {
-			go func() { <-time.After(notificationTimeout); n.Cancel() }()
-		}
+		notifications.transient(notice{title: "Message Not Sent",
+			full:    fmt.Sprintf("Failed to send message to %s", event.Nickname),
+			sender:  fmt.Sprintf("Failed to send message to %s", event.Nickname),
+			generic: "Failed to send a message",
+		})
 	case *catshadow.MessageReceivedEvent:
-		// do not notify for the focused conversation
-		p := a.stack.Current()
-		switch p := p.(type) {
+		// silently drop messages from blocked contacts
+		if isBlocked(a.c, event.Nickname) {
+			dropMessage(a.c, event)
+			return nil
+		}
+		// bring archived conversations back to the contact list
+		setFlag(a.c, archivedFlag, event.Nickname, false)
+		// do not notify for the focused conversation, whether it is a page
+		// or shown beside the contact list
+		var shown *conversationPage
+		switch p := a.stack.Current().(type) {
 		case *conversationPage:
-			// XXX: on android, input focus is not lost when the application does not have foreground
-			// but system.Stage is changed. On desktop linux, the stage does not change, but window focus is lost.
-			if p.nickname == event.Nickname && a.stage == system.StageRunning && a.focus {
-				a.w.Invalidate()
-				return nil
-			}
+			shown = p
+		case *HomePage:
+			shown = p.conversation
 		}
-		// emit a notification in all other cases
-		if n, err := notify.Push("Message Received", fmt.Sprintf("Message Received from %s", event.Nickname)); err == nil {
-			if o, ok := notifications[event.Nickname]; ok {
-				// cancel old notification before replacing with a new one
-				o.Cancel()
-			}
-			notifications[event.Nickname] = n
+		// XXX: on android, input focus is not lost when the application does not have foreground
+		// but system.Stage is changed. On desktop linux, the stage does not change, but window focus is lost.
+		if shown != nil && shown.nickname == event.Nickname && a.stage == system.StageRunning && a.focus {
+			markRead(a.c, event.Nickname)
+			a.w.Invalidate()
+			return nil
 		}
+		// emit a notification in all other cases, subject to the notification policy
+		notifications.message(event.Nickname, event.Message)
 	case *catshadow.MessageSentEvent:
 	case *catshadow.MessageDeliveredEvent:
 	default:
@@ -310,19 +394,45 @@ func (a *App) handleGioEvents(e interface{}) error {
 		return errors.New("system.DestroyEvent receieved")
 	case system.FrameEvent:
 		gtx := layout.NewContext(a.ops, e)
-		key.InputOp{Tag: a.w, Keys: key.NameEscape + "|" + key.NameBack}.Add(a.ops)
+		a.watchTyping(gtx)
+		key.InputOp{Tag: a.w, Keys: keymap.set(scopeGlobal) + "|" + key.NameBack}.Add(a.ops)
 		for _, e := range gtx.Events(a.w) {
 			switch e := e.(type) {
 			case key.Event:
-				if (e.Name == key.NameEscape && e.State == key.Release) || e.Name == key.NameBack {
+				a.touch()
+				action := keymap.action(scopeGlobal, e)
+				switch {
+				case action == actionLock:
+					a.lock()
+				case action == actionHelp:
+					keyHelp.visible = true
+				case action == actionPalette && a.c != nil:
+					if palette.visible {
+						palette.visible = false
+					} else {
+						palette.open(a)
+					}
+				case action == actionZoomIn:
+					a.zoom(zoomStep)
+				case action == actionZoomOut:
+					a.zoom(-zoomStep)
+				case action == actionZoomReset:
+					a.zoom(0)
+				case action == actionBack || e.Name == key.NameBack:
 					if a.stack.Len() > 1 {
-						a.stack.Pop()
+						a.back()
 						a.w.Invalidate()
 					}
 				}
 			}
 		}
 		a.Layout(gtx)
+		a.watchActivity(gtx)
+		if a.wiped {
+			clipboard.WriteOp{}.Add(gtx.Ops)
+			e.Frame(gtx.Ops)
+			return errWiped
+		}
 		e.Frame(gtx.Ops)
 	case system.StageEvent:
 		fmt.Printf("StageEvent %s received\n", e.Stage)
@@ -332,7 +442,9 @@ func (a *App) handleGioEvents(e interface{}) error {
 				a.stack.Push(newSignInPage(a))
 			}
 		}
-		if e.Stage == system.StagePaused {
+		if e.Stage == system.StagePaused && a.c != nil && lockOnPause(a.c) {
+			a.lock()
+		} else if e.Stage == system.StagePaused {
 			var err error
 			a.endBg, err = app.Start("Is running in the background", "")
 			if err != nil {
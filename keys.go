@@ -0,0 +1,387 @@
+package main
+
+import (
+	"image"
+	"runtime"
+	"strings"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+// key scopes; global shortcuts work on every page, and page shortcuts only
+// while the page is shown
+const (
+	scopeGlobal       = "global"
+	scopeHome         = "home"
+	scopeConversation = "conversation"
+	scopeSignIn       = "signin"
+)
+
+// keyboard actions, by the stable names under which remapped keys are saved
+const (
+	actionBack           = "back"
+	actionHelp           = "help"
+	actionLock           = "lock"
+	actionPalette        = "command-palette"
+	actionZoomIn         = "zoom-in"
+	actionZoomOut        = "zoom-out"
+	actionZoomReset      = "zoom-reset"
+	actionAddContact     = "add-contact"
+	actionSettings       = "settings"
+	actionConnect        = "toggle-connection"
+	actionSearch         = "search"
+	actionSelectPrevious = "select-previous"
+	actionSelectNext     = "select-next"
+	actionOpen           = "open-conversation"
+	actionEditContact    = "edit-contact"
+	actionScrollUp       = "scroll-up"
+	actionScrollDown     = "scroll-down"
+	actionPageUp         = "page-up"
+	actionPageDown       = "page-down"
+	actionPanicWipe      = "panic-wipe"
+)
+
+// keyAction is an action that can be bound to keys. Keys are written in the
+// key.Set syntax of gio, which is also how remapped keys are saved.
+type keyAction struct {
+	id    string
+	scope string
+	desc  string
+	keys  string
+}
+
+var keyActions = []*keyAction{
+	{actionBack, scopeGlobal, "Go back", key.NameEscape},
+	{actionHelp, scopeGlobal, "Show keyboard shortcuts", key.NameF1},
+	{actionLock, scopeGlobal, "Lock", "Short-L"},
+	{actionPalette, scopeGlobal, "Open the command palette", "Short-K"},
+	// the key set syntax splits modifiers from the key at the last dash, so
+	// it cannot name the minus key; zooming out is bound to Ctrl+Shift+-
+	// instead, which is reported as the underscore key
+	{actionZoomIn, scopeGlobal, "Zoom in", "Short-(Shift)-[=,+]"},
+	{actionZoomOut, scopeGlobal, "Zoom out", "Short-(Shift)-_"},
+	{actionZoomReset, scopeGlobal, "Reset zoom", "Short-0"},
+	{actionAddContact, scopeHome, "Add contact", key.NameF2},
+	{actionSettings, scopeHome, "Settings", key.NameF3},
+	{actionConnect, scopeHome, "Connect or disconnect", key.NameF4},
+	{actionSearch, scopeHome, "Search contacts", "Short-F"},
+	{actionSelectPrevious, scopeHome, "Select previous contact", key.NameUpArrow},
+	{actionSelectNext, scopeHome, "Select next contact", key.NameDownArrow},
+	{actionOpen, scopeHome, "Open selected conversation", key.NameReturn},
+	{actionEditContact, scopeConversation, "Edit contact", key.NameF5},
+	{actionScrollUp, scopeConversation, "Scroll up", key.NameUpArrow},
+	{actionScrollDown, scopeConversation, "Scroll down", key.NameDownArrow},
+	{actionPageUp, scopeConversation, "Scroll up a page", key.NamePageUp},
+	{actionPageDown, scopeConversation, "Scroll down a page", key.NamePageDown},
+	{actionPanicWipe, scopeSignIn, "Wipe everything (if started with -w)", wipeChord},
+}
+
+// editingKeys are used by text fields and cannot be bound
+var editingKeys = &keyAction{desc: "text editing", keys: "Short-[C,V,X,A]|Short-(Shift)-Z"}
+
+// bindableKeys are the keys that can be captured when remapping an action.
+// Keys that are part of the key set syntax cannot be bound.
+const bindableKeys = "(Short)-(Shift)-(Alt)-[A,B,C,D,E,F,G,H,I,J,K,L,M,N,O,P,Q,R,S,T,U,V,W,X,Y,Z," +
+	"0,1,2,3,4,5,6,7,8,9,=,+,_,.,/,;,',`,\\,F1,F2,F3,F4,F5,F6,F7,F8,F9,F10,F11,F12," +
+	key.NameLeftArrow + "," + key.NameRightArrow + "," + key.NameUpArrow + "," + key.NameDownArrow + "," +
+	key.NameReturn + "," + key.NameEscape + "," + key.NameHome + "," + key.NameEnd + "," +
+	key.NamePageUp + "," + key.NamePageDown + "," + key.NameDeleteBackward + "," + key.NameDeleteForward + "," + key.NameSpace + "]"
+
+// keyRegistry maps actions to their keys, with the keys remapped by the user
+type keyRegistry struct {
+	remapped map[string]string
+}
+
+var keymap = &keyRegistry{remapped: make(map[string]string)}
+
+// loadKeyBindings reads the remapped keys of the unlocked client
+func loadKeyBindings(c *catshadow.Client) {
+	keymap.remapped = make(map[string]string)
+	if b, err := c.GetBlob("KeyBindings"); err == nil {
+		cbor.Unmarshal(b, &keymap.remapped)
+	}
+}
+
+// keys returns the keys bound to an action
+func (r *keyRegistry) keys(a *keyAction) string {
+	if k, ok := r.remapped[a.id]; ok {
+		return k
+	}
+	return a.keys
+}
+
+// lookup returns the action with id
+func (r *keyRegistry) lookup(id string) *keyAction {
+	for _, a := range keyActions {
+		if a.id == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// set returns the keys of the actions of a scope for a key.InputOp. Pages
+// receive the back action as well, so that they can act on it first.
+func (r *keyRegistry) set(scope string) key.Set {
+	var keys []string
+	for _, a := range keyActions {
+		if a.scope == scope || (scope != scopeGlobal && a.id == actionBack) {
+			keys = append(keys, r.keys(a))
+		}
+	}
+	return key.Set(strings.Join(keys, "|"))
+}
+
+// action returns the action of a scope bound to the key pressed, or "" if there is none
+func (r *keyRegistry) action(scope string, e key.Event) string {
+	if e.State != key.Press {
+		return ""
+	}
+	for _, a := range keyActions {
+		if a.scope != scope && (scope == scopeGlobal || a.id != actionBack) {
+			continue
+		}
+		if key.Set(r.keys(a)).Contains(e.Name, e.Modifiers) {
+			return a.id
+		}
+	}
+	return ""
+}
+
+// conflict returns the action that keys would clash with if bound to the
+// action id, or nil. Global keys clash with the keys of every page.
+func (r *keyRegistry) conflict(id, keys string) *keyAction {
+	scope := r.lookup(id).scope
+	for _, k := range expandKeys(keys) {
+		if key.Set(editingKeys.keys).Contains(k.name, k.mods) {
+			return editingKeys
+		}
+		for _, a := range keyActions {
+			if a.id == id || (a.scope != scope && a.scope != scopeGlobal && scope != scopeGlobal) {
+				continue
+			}
+			if key.Set(r.keys(a)).Contains(k.name, k.mods) {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// bind remaps the keys of an action, or restores its default keys if keys is empty
+func (r *keyRegistry) bind(c *catshadow.Client, id, keys string) {
+	if keys == "" || keys == r.lookup(id).keys {
+		delete(r.remapped, id)
+	} else {
+		r.remapped[id] = keys
+	}
+	if len(r.remapped) == 0 {
+		c.DeleteBlob("KeyBindings")
+		return
+	}
+	if b, err := cbor.Marshal(r.remapped); err == nil {
+		c.AddBlob("KeyBindings", b)
+	}
+}
+
+// keyCombo is a key pressed with modifiers
+type keyCombo struct {
+	name string
+	mods key.Modifiers
+}
+
+// modifierNames are the modifiers of the key set syntax
+var modifierNames = map[string]key.Modifiers{
+	"Short":         key.ModShortcut,
+	"ShortAlt":      key.ModShortcutAlt,
+	key.NameCtrl:    key.ModCtrl,
+	key.NameShift:   key.ModShift,
+	key.NameAlt:     key.ModAlt,
+	key.NameSuper:   key.ModSuper,
+	key.NameCommand: key.ModCommand,
+}
+
+// expandKeys returns every key combination matched by keys
+func expandKeys(keys string) []keyCombo {
+	var combos []keyCombo
+	for _, chord := range strings.Split(keys, "|") {
+		var mods, optional []key.Modifiers
+		var names []string
+		modSet, keySet := "", chord
+		if sep := strings.LastIndex(chord, "-"); sep != -1 {
+			modSet, keySet = chord[:sep], chord[sep+1:]
+		}
+		if strings.HasPrefix(keySet, "[") && strings.HasSuffix(keySet, "]") {
+			names = strings.Split(keySet[1:len(keySet)-1], ",")
+		} else {
+			names = []string{keySet}
+		}
+		if modSet != "" {
+			for _, m := range strings.Split(modSet, "-") {
+				if strings.HasPrefix(m, "(") && strings.HasSuffix(m, ")") {
+					optional = append(optional, modifierNames[m[1:len(m)-1]])
+				} else {
+					mods = append(mods, modifierNames[m])
+				}
+			}
+		}
+		var required key.Modifiers
+		for _, m := range mods {
+			required |= m
+		}
+		// every subset of the optional modifiers
+		for i := 0; i < 1<<len(optional); i++ {
+			m := required
+			for j, o := range optional {
+				if i&(1<<j) != 0 {
+					m |= o
+				}
+			}
+			for _, name := range names {
+				combos = append(combos, keyCombo{name: name, mods: m})
+			}
+		}
+	}
+	return combos
+}
+
+// keysOf returns the keys of a key event in the key set syntax
+func keysOf(e key.Event) string {
+	var mods []string
+	m := e.Modifiers
+	if m.Contain(key.ModShortcut) {
+		mods = append(mods, "Short")
+		m &^= key.ModShortcut
+	}
+	if m != 0 {
+		mods = append(mods, m.String())
+	}
+	mods = append(mods, e.Name)
+	return strings.Join(mods, "-")
+}
+
+// keysLabel returns keys as shown to the user, such as Ctrl+Shift+F
+func keysLabel(keys string) string {
+	var labels []string
+	for _, chord := range strings.Split(keys, "|") {
+		var parts []string
+		keySet := chord
+		if sep := strings.LastIndex(chord, "-"); sep != -1 {
+			for _, m := range strings.Split(chord[:sep], "-") {
+				switch {
+				case strings.HasPrefix(m, "("):
+					// optional modifiers are not shown
+				case m == "Short" && runtime.GOOS == "darwin":
+					parts = append(parts, key.NameCommand)
+				case m == "Short":
+					parts = append(parts, key.NameCtrl)
+				default:
+					parts = append(parts, m)
+				}
+			}
+			keySet = chord[sep+1:]
+		}
+		keySet = strings.TrimSuffix(strings.TrimPrefix(keySet, "["), "]")
+		parts = append(parts, strings.ReplaceAll(keySet, ",", " or "))
+		labels = append(labels, strings.Join(parts, "+"))
+	}
+	return strings.Join(labels, ", ")
+}
+
+// keyScoped is implemented by pages that have keyboard shortcuts of their own
+type keyScoped interface {
+	keyScopes() []string
+}
+
+// helpOverlay lists the keyboard shortcuts of the current page
+type helpOverlay struct {
+	visible bool
+	close   *widget.Clickable
+	list    *layout.List
+}
+
+var keyHelp = &helpOverlay{close: &widget.Clickable{}, list: &layout.List{Axis: layout.Vertical}}
+
+// Layout shows the shortcuts of the global scope and of the scopes of page
+// over the page. Any click, the back key or the help key closes it.
+func (h *helpOverlay) Layout(gtx C, page Page) D {
+	if !h.visible {
+		return D{}
+	}
+	for _, e := range gtx.Events(h) {
+		if e, ok := e.(key.Event); ok {
+			if id := keymap.action(scopeGlobal, e); id == actionBack || id == actionHelp {
+				h.visible = false
+			}
+		}
+	}
+	if h.close.Clicked() {
+		h.visible = false
+	}
+	if !h.visible {
+		return D{}
+	}
+	scopes := []string{scopeGlobal}
+	if p, ok := page.(keyScoped); ok {
+		scopes = append(scopes, p.keyScopes()...)
+	}
+	var actions []*keyAction
+	for _, s := range scopes {
+		for _, a := range keyActions {
+			if a.scope == s {
+				actions = append(actions, a)
+			}
+		}
+	}
+
+	// dim the page and take the clicks and keys meant for it
+	gtx.Constraints.Min = gtx.Constraints.Max
+	h.close.Layout(gtx, func(gtx C) D {
+		semantic.Button.Add(gtx.Ops)
+		semantic.DescriptionOp("Close keyboard shortcuts").Add(gtx.Ops)
+		return fill{argb(0xc0000000)}.Layout(gtx)
+	})
+	area := clip.Rect(image.Rectangle{Max: gtx.Constraints.Max}).Push(gtx.Ops)
+	pointer.InputOp{Tag: h, Types: pointer.Scroll, ScrollBounds: image.Rectangle{Min: image.Pt(-1<<20, -1<<20), Max: image.Pt(1<<20, 1<<20)}}.Add(gtx.Ops)
+	key.InputOp{Tag: h, Keys: keymap.set(scopeGlobal)}.Add(gtx.Ops)
+	key.FocusOp{Tag: h}.Add(gtx.Ops)
+	area.Pop()
+
+	return layout.Center.Layout(gtx, func(gtx C) D {
+		gtx.Constraints.Min.X = 0
+		gtx.Constraints.Min.Y = 0
+		if max := gtx.Dp(unit.Dp(480)); gtx.Constraints.Max.X > max {
+			gtx.Constraints.Max.X = max
+		}
+		bg := Background{
+			Color:  th.Bg,
+			Inset:  layout.UniformInset(unit.Dp(16)),
+			Radius: unit.Dp(10),
+		}
+		return bg.Layout(gtx, func(gtx C) D {
+			return h.list.Layout(gtx, len(actions)+1, func(gtx C, i int) D {
+				if i == 0 {
+					return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, material.H6(th, "Keyboard Shortcuts").Layout)
+				}
+				a := actions[i-1]
+				return layout.Flex{Alignment: layout.Baseline}.Layout(gtx,
+					layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+						return inset.Layout(gtx, material.Body2(th, keysLabel(keymap.keys(a))).Layout)
+					}),
+					layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+						return inset.Layout(gtx, material.Body2(th, a.desc).Layout)
+					}),
+				)
+			})
+		})
+	})
+}
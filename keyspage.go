@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// KeysPage remaps the keyboard shortcuts
+type KeysPage struct {
+	a        *App
+	back     *widget.Clickable
+	resetAll *widget.Clickable
+	change   map[string]*widget.Clickable
+	reset    map[string]*widget.Clickable
+	list     *layout.List
+	// capturing is the action waiting for its new keys
+	capturing string
+	status    string
+}
+
+// ShowKeysClick is the event that requests the keyboard shortcuts page
+type ShowKeysClick struct{}
+
+var scopeTitles = map[string]string{
+	scopeGlobal:       "Everywhere",
+	scopeHome:         "Contacts",
+	scopeConversation: "Conversation",
+	scopeSignIn:       "Sign In",
+}
+
+// Layout returns the actions with their keys, grouped by scope
+func (p *KeysPage) Layout(gtx layout.Context) layout.Dimensions {
+	if p.capturing != "" {
+		key.InputOp{Tag: p, Keys: bindableKeys}.Add(gtx.Ops)
+		key.FocusOp{Tag: p}.Add(gtx.Ops)
+	}
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	rows := []layout.Widget{}
+	scope := ""
+	for _, a := range keyActions {
+		a := a
+		if a.scope != scope {
+			scope = a.scope
+			title := scopeTitles[scope]
+			rows = append(rows, func(gtx C) D {
+				return inset.Layout(gtx, material.Body1(th, title).Layout)
+			})
+		}
+		rows = append(rows, func(gtx C) D {
+			label := keysLabel(keymap.keys(a))
+			if p.capturing == a.id {
+				label = "Press keys…"
+			}
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body2(th, a.desc).Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					children := []layout.FlexChild{
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, label).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Button(th, p.change[a.id], "Change").Layout)
+						}),
+					}
+					if _, ok := keymap.remapped[a.id]; ok {
+						children = append(children, layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Button(th, p.reset[a.id], "Default").Layout)
+						}))
+					}
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx, children...)
+				}),
+			)
+		})
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Keyboard Shortcuts").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, material.Body2(th, p.status).Layout)
+			}),
+			layout.Flexed(1, func(gtx C) D {
+				return p.list.Layout(gtx, len(rows), func(gtx C, i int) D {
+					return rows[i](gtx)
+				})
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.Button(th, p.resetAll, "Restore All Defaults").Layout(gtx)
+			}),
+		)
+	})
+}
+
+// Event starts capturing the keys of an action, and binds the captured keys
+// unless they are already used
+func (p *KeysPage) Event(gtx layout.Context) interface{} {
+	for _, e := range gtx.Events(p) {
+		e, ok := e.(key.Event)
+		if !ok || e.State != key.Press || p.capturing == "" {
+			continue
+		}
+		id := p.capturing
+		p.capturing = ""
+		if e.Name == key.NameEscape && e.Modifiers == 0 {
+			p.status = ""
+			return RedrawEvent{}
+		}
+		keys := keysOf(e)
+		if other := keymap.conflict(id, keys); other != nil {
+			p.status = fmt.Sprintf("%s is already used for %s", keysLabel(keys), other.desc)
+			return RedrawEvent{}
+		}
+		keymap.bind(p.a.c, id, keys)
+		p.status = fmt.Sprintf("%s bound to %s", keysLabel(keys), keymap.lookup(id).desc)
+		return RedrawEvent{}
+	}
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	for id, click := range p.change {
+		if click.Clicked() {
+			p.capturing = id
+			p.status = "Press the new keys, or Escape to cancel"
+			return RedrawEvent{}
+		}
+	}
+	for id, click := range p.reset {
+		if click.Clicked() {
+			if other := keymap.conflict(id, keymap.lookup(id).keys); other != nil {
+				p.status = fmt.Sprintf("The default keys are used for %s", other.desc)
+				return RedrawEvent{}
+			}
+			keymap.bind(p.a.c, id, "")
+			p.status = ""
+			return RedrawEvent{}
+		}
+	}
+	if p.resetAll.Clicked() {
+		for _, a := range keyActions {
+			keymap.bind(p.a.c, a.id, "")
+		}
+		p.capturing = ""
+		p.status = "Default shortcuts restored"
+		return RedrawEvent{}
+	}
+	return nil
+}
+
+func (p *KeysPage) Start(stop <-chan struct{}) {
+}
+
+func newKeysPage(a *App) *KeysPage {
+	p := &KeysPage{a: a,
+		back:     &widget.Clickable{},
+		resetAll: &widget.Clickable{},
+		change:   make(map[string]*widget.Clickable),
+		reset:    make(map[string]*widget.Clickable),
+		list:     &layout.List{Axis: layout.Vertical},
+	}
+	for _, a := range keyActions {
+		p.change[a.id] = &widget.Clickable{}
+		p.reset[a.id] = &widget.Clickable{}
+	}
+	return p
+}
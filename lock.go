@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/widget"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// maxAutoLockMinutes is the longest idle timeout that can be chosen
+const maxAutoLockMinutes = 60.0
+
+var lockIcon, _ = widget.NewIcon(icons.ActionLock)
+
+// LockClick is the event that locks katzen immediately
+type LockClick struct{}
+
+// autoLockTimeout returns how long katzen may be idle before it locks, or 0
+// if it never locks by itself
+func autoLockTimeout(c *catshadow.Client) time.Duration {
+	if b, err := c.GetBlob("AutoLockMinutes"); err == nil {
+		if m, err := strconv.Atoi(string(b)); err == nil && m > 0 {
+			return time.Duration(m) * time.Minute
+		}
+	}
+	return 0
+}
+
+// lockOnPause returns true if katzen locks when it is sent to the background
+func lockOnPause(c *catshadow.Client) bool {
+	_, err := c.GetBlob("LockOnPause")
+	return err == nil
+}
+
+// signOut forgets the client, which must already be shut down, along with the
+// caches holding contact details, and returns to the sign in page showing status
+func (a *App) signOut(status string) {
+	isConnected = false
+	isConnecting = false
+	a.c = nil
+	avatars = make(map[string]layout.Widget)
+	notifications.clear()
+	katzenClipboard.expire()
+	homeFilter.query = ""
+	selectedIdx = 0
+	keyHelp.visible = false
+	palette.visible = false
+	p := newSignInPage(a)
+	p.errMsg = status
+	a.stack.Clear(p)
+}
+
+// lock shuts down the client and signs out. Shutdown waits for the
+// StateWriter, so messages that were queued for sending are saved first.
+func (a *App) lock() {
+	if a.c == nil {
+		return
+	}
+	shutdownClient(a.c)
+	a.signOut("Locked")
+	a.w.Invalidate()
+}
+
+// touch records user activity, postponing the idle auto-lock
+func (a *App) touch() {
+	a.lastActive = time.Now()
+}
+
+// lockIfIdle locks katzen when it has been idle for longer than the auto-lock timeout
+func (a *App) lockIfIdle() {
+	if a.c == nil {
+		return
+	}
+	if timeout := autoLockTimeout(a.c); timeout > 0 && time.Since(a.lastActive) > timeout {
+		a.lock()
+	}
+}
+
+// typingKeys are the keys that type text. An editor takes their text as
+// edit events but not the key events themselves, which watchTyping receives.
+const typingKeys = "(Shift)-[A,B,C,D,E,F,G,H,I,J,K,L,M,N,O,P,Q,R,S,T,U,V,W,X,Y,Z,0,1,2,3,4,5,6,7,8,9," + key.NameSpace + "]"
+
+// watchTyping observes the keys typed into an editor. It must be added
+// before the pages and the global shortcuts, so that any handler that wants
+// one of these keys gets it first.
+func (a *App) watchTyping(gtx layout.Context) {
+	key.InputOp{Tag: &a.lastActive, Keys: typingKeys}.Add(gtx.Ops)
+}
+
+// watchActivity observes pointer input anywhere in the window without
+// consuming it, and the keys received by watchTyping. It must be added
+// after the pages are laid out, so that it is on top of their handlers.
+func (a *App) watchActivity(gtx layout.Context) {
+	for range gtx.Events(&a.lastActive) {
+		a.touch()
+	}
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+	defer pointer.PassOp{}.Push(gtx.Ops).Pop()
+	pointer.InputOp{Tag: &a.lastActive, Types: pointer.Press | pointer.Move}.Add(gtx.Ops)
+}
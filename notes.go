@@ -0,0 +1,216 @@
+package main
+
+import (
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+var addFieldIcon, _ = widget.NewIcon(icons.ContentAdd)
+
+// contactField is a named detail about a contact, such as a real name or key fingerprint
+type contactField struct {
+	Key   string
+	Value string
+}
+
+// getNotes returns the private notes about a contact
+func getNotes(c *catshadow.Client, nickname string) string {
+	if b, err := c.GetBlob("notes://" + nickname); err == nil {
+		return string(b)
+	}
+	return ""
+}
+
+// getFields returns the custom fields of a contact, in the order they were added
+func getFields(c *catshadow.Client, nickname string) []contactField {
+	fields := make([]contactField, 0)
+	if b, err := c.GetBlob("fields://" + nickname); err == nil {
+		cbor.Unmarshal(b, &fields)
+	}
+	return fields
+}
+
+// setNotes stores the notes and fields of a contact, removing the blobs when empty
+func setNotes(c *catshadow.Client, nickname, notes string, fields []contactField) error {
+	if strings.TrimSpace(notes) == "" {
+		c.DeleteBlob("notes://" + nickname)
+	} else if err := c.AddBlob("notes://"+nickname, []byte(notes)); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		c.DeleteBlob("fields://" + nickname)
+		return nil
+	}
+	b, err := cbor.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return c.AddBlob("fields://"+nickname, b)
+}
+
+// notesContain returns true if the notes or fields of a contact contain query, ignoring case
+func notesContain(c *catshadow.Client, nickname, query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return false
+	}
+	if strings.Contains(strings.ToLower(getNotes(c, nickname)), query) {
+		return true
+	}
+	for _, f := range getFields(c, nickname) {
+		if strings.Contains(strings.ToLower(f.Key), query) || strings.Contains(strings.ToLower(f.Value), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldEditor holds the widgets of one custom field
+type fieldEditor struct {
+	key    *widget.Editor
+	value  *widget.Editor
+	remove *widget.Clickable
+}
+
+func newFieldEditor(f contactField) *fieldEditor {
+	e := &fieldEditor{
+		key:    &widget.Editor{SingleLine: true},
+		value:  &widget.Editor{SingleLine: true},
+		remove: &widget.Clickable{},
+	}
+	e.key.SetText(f.Key)
+	e.value.SetText(f.Value)
+	return e
+}
+
+// ContactNotesPage edits the private notes and custom fields of a contact
+type ContactNotesPage struct {
+	a        *App
+	nickname string
+	back     *widget.Clickable
+	save     *widget.Clickable
+	add      *widget.Clickable
+	notes    *widget.Editor
+	fields   []*fieldEditor
+	list     *layout.List
+}
+
+// ContactNotes is the event that requests the notes page of a contact
+type ContactNotes struct {
+	nickname string
+}
+
+// Layout returns the notes editor followed by the custom fields
+func (p *ContactNotesPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Notes for "+p.nickname).Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Flexed(1, func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					// notes, one row per field, and the add field button
+					return p.list.Layout(gtx, len(p.fields)+3, func(gtx C, i int) D {
+						switch {
+						case i == 0:
+							return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+								layout.Rigid(material.Body2(th, "Notes").Layout),
+								layout.Rigid(func(gtx C) D {
+									gtx.Constraints.Min.Y = gtx.Dp(unit.Dp(96))
+									return material.Editor(th, p.notes, "Private notes about "+p.nickname).Layout(gtx)
+								}),
+							)
+						case i == 1:
+							return inset.Layout(gtx, material.Body2(th, "Details").Layout)
+						case i < len(p.fields)+2:
+							f := p.fields[i-2]
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+								layout.Flexed(.35, func(gtx C) D {
+									return inset.Layout(gtx, material.Editor(th, f.key, "Name").Layout)
+								}),
+								layout.Flexed(.65, func(gtx C) D {
+									return inset.Layout(gtx, material.Editor(th, f.value, "Value").Layout)
+								}),
+								layout.Rigid(button(th, f.remove, removeIcon, "Remove detail").Layout),
+							)
+						default:
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+								layout.Rigid(button(th, p.add, addFieldIcon, "Add detail").Layout),
+								layout.Flexed(1, fill{th.Bg}.Layout),
+								layout.Rigid(material.Button(th, p.save, "Save").Layout),
+							)
+						}
+					})
+				})
+			}),
+		)
+	})
+}
+
+// Event handles adding and removing fields and saving the notes
+func (p *ContactNotesPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.add.Clicked() {
+		f := newFieldEditor(contactField{})
+		f.key.Focus()
+		p.fields = append(p.fields, f)
+		return RedrawEvent{}
+	}
+	for i, f := range p.fields {
+		if f.remove.Clicked() {
+			p.fields = append(p.fields[:i], p.fields[i+1:]...)
+			return RedrawEvent{}
+		}
+	}
+	if p.save.Clicked() {
+		fields := make([]contactField, 0, len(p.fields))
+		for _, f := range p.fields {
+			key, value := strings.TrimSpace(f.key.Text()), strings.TrimSpace(f.value.Text())
+			if key == "" && value == "" {
+				continue
+			}
+			fields = append(fields, contactField{Key: key, Value: value})
+		}
+		setNotes(p.a.c, p.nickname, p.notes.Text(), fields)
+		return BackEvent{}
+	}
+	return nil
+}
+
+func (p *ContactNotesPage) Start(stop <-chan struct{}) {
+}
+
+func newContactNotesPage(a *App, nickname string) *ContactNotesPage {
+	p := &ContactNotesPage{a: a, nickname: nickname,
+		back:  &widget.Clickable{},
+		save:  &widget.Clickable{},
+		add:   &widget.Clickable{},
+		notes: &widget.Editor{},
+		list:  &layout.List{Axis: layout.Vertical},
+	}
+	p.notes.SetText(getNotes(a.c, nickname))
+	for _, f := range getFields(a.c, nickname) {
+		p.fields = append(p.fields, newFieldEditor(f))
+	}
+	return p
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gioui.org/widget"
+	"gioui.org/x/notify"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// notifyMode is the per-contact notification setting
+type notifyMode int
+
+const (
+	notifyAlways notifyMode = iota
+	notifyMuteUntil
+	notifyMuteForever
+	notifyBadgeOnly
+)
+
+const (
+	defaultQuietStart = 22
+	defaultQuietEnd   = 7
+	// maxPreviewLength is the number of characters of a message shown in a notification
+	maxPreviewLength = 64
+)
+
+// notification privacy levels, stored in the "NotificationPrivacy" blob
+const (
+	privacyFull    = "full"
+	privacySender  = "sender"
+	privacyGeneric = "generic"
+)
+
+var mutedIcon, _ = widget.NewIcon(icons.SocialNotificationsOff)
+
+// notifyRule is stored in the "notify://" blob of a contact
+type notifyRule struct {
+	Mode  notifyMode
+	Until time.Time
+}
+
+// getNotifyRule returns the notification rule of a contact, expiring
+// temporary mutes
+func getNotifyRule(c *catshadow.Client, nickname string) notifyRule {
+	r := notifyRule{}
+	if b, err := c.GetBlob("notify://" + nickname); err == nil {
+		if err := cbor.Unmarshal(b, &r); err != nil {
+			return notifyRule{}
+		}
+	}
+	if r.Mode == notifyMuteUntil && time.Now().After(r.Until) {
+		return notifyRule{}
+	}
+	return r
+}
+
+// setNotifyRule stores the notification rule of a contact
+func setNotifyRule(c *catshadow.Client, nickname string, r notifyRule) {
+	if r.Mode == notifyAlways {
+		c.DeleteBlob("notify://" + nickname)
+		return
+	}
+	if b, err := cbor.Marshal(r); err == nil {
+		c.AddBlob("notify://"+nickname, b)
+	}
+}
+
+// isMuted returns true if the contact is muted, either for a period or
+// forever. Muted contacts neither notify nor show an unread badge.
+func isMuted(c *catshadow.Client, nickname string) bool {
+	mode := getNotifyRule(c, nickname).Mode
+	return mode == notifyMuteUntil || mode == notifyMuteForever
+}
+
+// quietHours returns the start and end hour of quiet hours, and whether they are enabled
+func quietHours(c *catshadow.Client) (int, int, bool) {
+	start, end := defaultQuietStart, defaultQuietEnd
+	if b, err := c.GetBlob("QuietHoursStart"); err == nil {
+		if h, err := strconv.Atoi(string(b)); err == nil {
+			start = h
+		}
+	}
+	if b, err := c.GetBlob("QuietHoursEnd"); err == nil {
+		if h, err := strconv.Atoi(string(b)); err == nil {
+			end = h
+		}
+	}
+	_, err := c.GetBlob("QuietHours")
+	return start, end, err == nil
+}
+
+// inQuietHours returns true if t falls within the configured quiet hours,
+// which may wrap around midnight
+func inQuietHours(c *catshadow.Client, t time.Time) bool {
+	start, end, enabled := quietHours(c)
+	if !enabled || start == end {
+		return false
+	}
+	h := t.Hour()
+	if start < end {
+		return h >= start && h < end
+	}
+	return h >= start || h < end
+}
+
+// notificationPrivacy returns the configured notification privacy level
+func notificationPrivacy(c *catshadow.Client) string {
+	if c != nil {
+		if b, err := c.GetBlob("NotificationPrivacy"); err == nil {
+			switch string(b) {
+			case privacyFull, privacyGeneric:
+				return string(b)
+			}
+		}
+	}
+	return privacySender
+}
+
+// notice is the content of a notification at each privacy level
+type notice struct {
+	title   string
+	full    string // names the contact and may include message content
+	sender  string // names the contact only
+	generic string // reveals nothing about contacts
+}
+
+// plainNotice returns a notice that does not concern any contact
+func plainNotice(title, text string) notice {
+	return notice{title: title, full: text, sender: text, generic: text}
+}
+
+// text returns the title and text of the notice for the privacy level
+func (m notice) text(level string) (string, string) {
+	switch level {
+	case privacyFull:
+		return m.title, m.full
+	case privacyGeneric:
+		return "Katzen", m.generic
+	default:
+		return m.title, m.sender
+	}
+}
+
+// messagePreview returns the start of a message on a single line, with
+// control characters and markup removed
+func messagePreview(plaintext []byte) string {
+	s := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r), unicode.IsControl(r):
+			return ' '
+		case strings.ContainsRune("*_`~#>|[]", r):
+			return -1
+		case !unicode.IsPrint(r):
+			return -1
+		}
+		return r
+	}, string(plaintext))
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxPreviewLength {
+		s = string(r[:maxPreviewLength]) + "…"
+	}
+	return s
+}
+
+// notifier applies the notification policy to every notification katzen
+// shows, and tracks the notifications of unread messages so that they can
+// be dismissed when the conversation is read
+type notifier struct {
+	a        *App
+	messages map[string]notify.Notification
+}
+
+func newNotifier(a *App) *notifier {
+	return &notifier{a: a, messages: make(map[string]notify.Notification)}
+}
+
+// quiet returns true if notifications are currently suppressed
+func (n *notifier) quiet() bool {
+	if n.a.c == nil {
+		return false
+	}
+	return inQuietHours(n.a.c, time.Now())
+}
+
+// privacy returns the current notification privacy level
+func (n *notifier) privacy() string {
+	return notificationPrivacy(n.a.c)
+}
+
+// transient shows a notification that is cancelled after notificationTimeout
+func (n *notifier) transient(m notice) {
+	if n.quiet() {
+		return
+	}
+	title, text := m.text(n.privacy())
+	go func() {
+		if o, err := notify.Push(title, text); err == nil {
+			<-time.After(notificationTimeout)
+			o.Cancel()
+		}
+	}()
+}
+
+// message shows a notification for a message received from nickname,
+// unless the contact is muted or badge only, or quiet hours are in effect
+func (n *notifier) message(nickname string, plaintext []byte) {
+	if n.quiet() || getNotifyRule(n.a.c, nickname).Mode != notifyAlways {
+		return
+	}
+	m := notice{title: "Message Received",
+		sender:  fmt.Sprintf("Message Received from %s", nickname),
+		generic: "New message",
+	}
+	level := n.privacy()
+	if level == privacyFull {
+		m.title = nickname
+		m.full = messagePreview(plaintext)
+	}
+	title, text := m.text(level)
+	if o, err := notify.Push(title, text); err == nil {
+		// cancel old notification before replacing with a new one
+		n.dismiss(nickname)
+		n.messages[nickname] = o
+	}
+}
+
+// dismiss cancels the message notification of a contact
+func (n *notifier) dismiss(nickname string) {
+	if o, ok := n.messages[nickname]; ok {
+		o.Cancel()
+		delete(n.messages, nickname)
+	}
+}
+
+// clear cancels all message notifications
+func (n *notifier) clear() {
+	for nickname := range n.messages {
+		n.dismiss(nickname)
+	}
+}
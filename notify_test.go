@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInQuietHours(t *testing.T) {
+	c := newOfflineClient(t)
+	at := func(hour int) time.Time {
+		return time.Date(2026, 10, 19, hour, 30, 0, 0, time.Local)
+	}
+	tests := []struct {
+		enabled    bool
+		start, end int
+		hour       int
+		want       bool
+	}{
+		{false, 22, 7, 23, false},
+		{true, 22, 7, 21, false},
+		{true, 22, 7, 22, true},
+		{true, 22, 7, 3, true},
+		{true, 22, 7, 7, false},
+		{true, 9, 17, 8, false},
+		{true, 9, 17, 12, true},
+		{true, 9, 17, 17, false},
+		{true, 0, 24, 0, true},
+		// an empty range is never quiet
+		{true, 8, 8, 8, false},
+	}
+	for _, test := range tests {
+		c.DeleteBlob("QuietHours")
+		if test.enabled {
+			c.AddBlob("QuietHours", []byte{})
+		}
+		c.AddBlob("QuietHoursStart", []byte(strconv.Itoa(test.start)))
+		c.AddBlob("QuietHoursEnd", []byte(strconv.Itoa(test.end)))
+		if got := inQuietHours(c, at(test.hour)); got != test.want {
+			t.Errorf("%02d:30 with quiet hours %d-%d (enabled %v): got %v, want %v",
+				test.hour, test.start, test.end, test.enabled, got, test.want)
+		}
+	}
+}
+
+func TestMessagePreview(t *testing.T) {
+	long := strings.Repeat("é", maxPreviewLength+1)
+	tests := []struct {
+		plaintext, want string
+	}{
+		{"hello", "hello"},
+		{"  two\n\nlines\t", "two lines"},
+		{"**bold** _and_ `code`", "bold and code"},
+		{"> quoted [link]", "quoted link"},
+		{"bell\a and\x1b[31mred", "bell and 31mred"},
+		{"zero\u200bwidth", "zerowidth"},
+		{long, strings.Repeat("é", maxPreviewLength) + "…"},
+		{long[:2*maxPreviewLength], long[:2*maxPreviewLength]},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := messagePreview([]byte(test.plaintext)); got != test.want {
+			t.Errorf("messagePreview(%q) = %q, want %q", test.plaintext, got, test.want)
+		}
+	}
+}
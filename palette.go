@@ -0,0 +1,281 @@
+package main
+
+import (
+	"image"
+	"sort"
+	"strings"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// paletteCommand is an entry of the command palette. Choosing it sends event
+// to App.update, as if a page had returned it.
+type paletteCommand struct {
+	title string
+	event interface{}
+	// action is the keyboard action of the command, if any, whose keys are shown
+	action string
+}
+
+// paletteCommands returns the commands of the palette. Features add their
+// commands by appending a function to this list.
+var paletteCommands = []func(a *App) []paletteCommand{
+	appCommands,
+	contactCommands,
+}
+
+// appCommands returns the commands that do not concern a contact
+func appCommands(a *App) []paletteCommand {
+	connect := paletteCommand{title: "Connect", event: OnlineClick{}, action: actionConnect}
+	if isConnected || isConnecting {
+		connect = paletteCommand{title: "Disconnect", event: OfflineClick{}, action: actionConnect}
+	}
+	return []paletteCommand{
+		{title: "Add contact", event: AddContactClick{}, action: actionAddContact},
+		connect,
+		{title: "Settings", event: ShowSettingsClick{}, action: actionSettings},
+		{title: "Pending key exchanges", event: ShowPendingClick{}},
+		{title: "Lock", event: LockClick{}, action: actionLock},
+		{title: "Keyboard shortcuts", event: ShowKeysClick{}},
+		{title: "Export or import address book", event: ShowAddressBookClick{}},
+		{title: "Back up statefile", event: ShowBackupClick{}},
+		{title: "Change passphrase", event: ShowChangePassphraseClick{}},
+	}
+}
+
+// contactCommands returns the commands of each contact
+func contactCommands(a *App) []paletteCommand {
+	var commands []paletteCommand
+	for _, contact := range getSortedContacts(a) {
+		nickname := contact.Nickname
+		commands = append(commands,
+			paletteCommand{title: "Open conversation with " + nickname, event: ChooseContactClick{nickname: nickname}},
+			paletteCommand{title: "Edit contact " + nickname, event: EditContact{nickname: nickname}},
+			paletteCommand{title: "Verify " + nickname, event: VerifyContact{nickname: nickname}},
+			paletteCommand{title: "Notes about " + nickname, event: ContactNotes{nickname: nickname}},
+			paletteCommand{title: "Export history with " + nickname, event: ExportHistory{nickname: nickname}},
+		)
+	}
+	return commands
+}
+
+// fuzzyScore returns how well query matches s, if the letters of query appear
+// in s in order. Consecutive letters and letters starting a word score higher.
+func fuzzyScore(query, s string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(s))
+	score, qi, last := 0, 0, -2
+	for i, r := range t {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			continue
+		}
+		switch {
+		case i == last+1:
+			score += 3
+		case i == 0 || t[i-1] == ' ':
+			score += 2
+		default:
+			score++
+		}
+		last = i
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// commandPalette finds commands and contacts by typing part of their name
+type commandPalette struct {
+	visible  bool
+	query    *widget.Editor
+	dismiss  *widget.Clickable
+	list     *layout.List
+	clicks   []*widget.Clickable
+	matches  []paletteCommand
+	selected int
+}
+
+var palette = &commandPalette{
+	query:   &widget.Editor{SingleLine: true, Submit: true},
+	dismiss: &widget.Clickable{},
+	list:    &layout.List{Axis: layout.Vertical},
+}
+
+// open shows the palette with every command
+func (p *commandPalette) open(a *App) {
+	p.visible = true
+	p.query.SetText("")
+	p.query.Focus()
+	p.refresh(a)
+}
+
+// refresh finds the commands matching the query, best matches first
+func (p *commandPalette) refresh(a *App) {
+	type match struct {
+		command paletteCommand
+		score   int
+	}
+	var matches []match
+	query := strings.TrimSpace(p.query.Text())
+	for _, commands := range paletteCommands {
+		for _, c := range commands(a) {
+			if score, ok := fuzzyScore(query, c.title); ok {
+				matches = append(matches, match{c, score})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	p.matches = p.matches[:0]
+	for _, m := range matches {
+		p.matches = append(p.matches, m.command)
+	}
+	for len(p.clicks) < len(p.matches) {
+		p.clicks = append(p.clicks, &widget.Clickable{})
+	}
+	p.selected = 0
+	p.list.Position.First = 0
+}
+
+// choose closes the palette and returns the event of the selected command
+func (p *commandPalette) choose(i int) interface{} {
+	p.visible = false
+	if i < len(p.matches) {
+		return p.matches[i].event
+	}
+	return RedrawEvent{}
+}
+
+// Event returns the event of the chosen command
+func (p *commandPalette) Event(gtx layout.Context, a *App) interface{} {
+	if !p.visible {
+		return nil
+	}
+	for _, e := range p.query.Events() {
+		switch e.(type) {
+		case widget.ChangeEvent:
+			p.refresh(a)
+		case widget.SubmitEvent:
+			return p.choose(p.selected)
+		}
+	}
+	for _, e := range gtx.Events(p) {
+		e, ok := e.(key.Event)
+		if !ok || e.State != key.Press {
+			continue
+		}
+		switch {
+		case e.Name == key.NameEscape:
+			p.visible = false
+			return RedrawEvent{}
+		case e.Name == key.NameUpArrow || e.Name == "P" && e.Modifiers.Contain(key.ModShortcut):
+			if p.selected > 0 {
+				p.selected--
+			}
+		case e.Name == key.NameDownArrow || e.Name == "N" && e.Modifiers.Contain(key.ModShortcut):
+			if p.selected < len(p.matches)-1 {
+				p.selected++
+			}
+		}
+		// keep the selected command in view
+		if p.selected < p.list.Position.First {
+			p.list.Position.First = p.selected
+		} else if n := p.list.Position.Count; n > 0 && p.selected >= p.list.Position.First+n {
+			p.list.Position.First = p.selected - n + 1
+		}
+		return RedrawEvent{}
+	}
+	for i, click := range p.clicks {
+		if click.Clicked() {
+			return p.choose(i)
+		}
+	}
+	if p.dismiss.Clicked() {
+		p.visible = false
+		return RedrawEvent{}
+	}
+	return nil
+}
+
+// Layout shows the query field and the matching commands over the page
+func (p *commandPalette) Layout(gtx layout.Context) layout.Dimensions {
+	if !p.visible {
+		return D{}
+	}
+	gtx.Constraints.Min = gtx.Constraints.Max
+	p.dismiss.Layout(gtx, func(gtx C) D {
+		semantic.Button.Add(gtx.Ops)
+		semantic.DescriptionOp("Close command palette").Add(gtx.Ops)
+		return fill{argb(0x80000000)}.Layout(gtx)
+	})
+	area := clip.Rect(image.Rectangle{Max: gtx.Constraints.Max}).Push(gtx.Ops)
+	pointer.InputOp{Tag: p, Types: pointer.Scroll, ScrollBounds: image.Rectangle{Min: image.Pt(-1<<20, -1<<20), Max: image.Pt(1<<20, 1<<20)}}.Add(gtx.Ops)
+	// the query field takes the other keys; the arrows are taken when it does not need them
+	key.InputOp{Tag: p, Keys: key.NameEscape + "|" + key.NameUpArrow + "|" + key.NameDownArrow + "|Short-[P,N]"}.Add(gtx.Ops)
+	area.Pop()
+
+	return layout.N.Layout(gtx, func(gtx C) D {
+		gtx.Constraints.Min = image.Point{}
+		if max := gtx.Dp(unit.Dp(480)); gtx.Constraints.Max.X > max {
+			gtx.Constraints.Max.X = max
+		}
+		gtx.Constraints.Min.X = gtx.Constraints.Max.X
+		gtx.Constraints.Max.Y = gtx.Constraints.Max.Y * 2 / 3
+		in := layout.Inset{Top: unit.Dp(48), Left: unit.Dp(8), Right: unit.Dp(8)}
+		return in.Layout(gtx, func(gtx C) D {
+			bg := Background{
+				Color:  th.Bg,
+				Inset:  layout.UniformInset(unit.Dp(8)),
+				Radius: unit.Dp(10),
+			}
+			return bg.Layout(gtx, func(gtx C) D {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						return inset.Layout(gtx, material.Editor(th, p.query, "Type a command or contact").Layout)
+					}),
+					layout.Flexed(1, func(gtx C) D {
+						if len(p.matches) == 0 {
+							return inset.Layout(gtx, material.Body2(th, "No matches").Layout)
+						}
+						return p.list.Layout(gtx, len(p.matches), p.layoutCommand)
+					}),
+				)
+			})
+		})
+	})
+}
+
+// layoutCommand returns a command with its keys, highlighted if selected
+func (p *commandPalette) layoutCommand(gtx C, i int) D {
+	c := p.matches[i]
+	return p.clicks[i].Layout(gtx, func(gtx C) D {
+		semantic.Button.Add(gtx.Ops)
+		semantic.SelectedOp(i == p.selected).Add(gtx.Ops)
+		bg := Background{Color: th.Bg, Inset: inset, Radius: unit.Dp(6)}
+		if i == p.selected {
+			bg.Color = th.ContrastBg
+		}
+		return bg.Layout(gtx, func(gtx C) D {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, material.Body1(th, c.title).Layout),
+				layout.Rigid(func(gtx C) D {
+					if c.action == "" {
+						return D{}
+					}
+					return material.Caption(th, keysLabel(keymap.keys(keymap.lookup(c.action)))).Layout(gtx)
+				}),
+			)
+		})
+	})
+}
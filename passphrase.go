@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/katzenpost/katzenpost/core/utils"
+)
+
+// ChangePassphrasePage re-encrypts the statefile with a new passphrase
+type ChangePassphrasePage struct {
+	a       *App
+	back    *widget.Clickable
+	submit  *widget.Clickable
+	current *widget.Editor
+	newPass *widget.Editor
+	confirm *widget.Editor
+	status  string
+}
+
+// ShowChangePassphraseClick is the event that requests the change passphrase page
+type ShowChangePassphraseClick struct{}
+
+// Layout returns the current, new and confirmed passphrase fields
+func (p *ChangePassphrasePage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Change Passphrase").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(th, "Katzen signs out while the statefile is encrypted with the new passphrase. Backups keep the passphrase they were made with.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.current, "Current passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.newPass, "New passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.confirm, "Confirm new passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Button(th, p.submit, "Change Passphrase").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+				})
+			}),
+		)
+	})
+}
+
+// clear empties the passphrase fields
+func (p *ChangePassphrasePage) clear() {
+	p.current.SetText("")
+	p.newPass.SetText("")
+	p.confirm.SetText("")
+}
+
+// Event checks the passphrases and re-encrypts the statefile
+func (p *ChangePassphrasePage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		p.clear()
+		return BackEvent{}
+	}
+	for _, e := range []*widget.Editor{p.current, p.newPass, p.confirm} {
+		for _, ev := range e.Events() {
+			if _, ok := ev.(widget.SubmitEvent); ok {
+				p.submit.Click()
+			}
+		}
+	}
+	if p.submit.Clicked() {
+		newPass := p.newPass.Text()
+		switch {
+		case len(newPass) != 0 && len(newPass) < minPasswordLen:
+			p.status = fmt.Sprintf("Password must be minimum %d characters long", minPasswordLen)
+			return RedrawEvent{}
+		case newPass != p.confirm.Text():
+			p.status = "The new passphrases do not match"
+			p.confirm.SetText("")
+			p.confirm.Focus()
+			return RedrawEvent{}
+		}
+		statefile := activeStatefile
+		oldPassphrase, newPassphrase := []byte(p.current.Text()), []byte(newPass)
+		defer utils.ExplicitBzero(oldPassphrase)
+		defer utils.ExplicitBzero(newPassphrase)
+
+		// check the current passphrase before signing out
+		state, err := decryptStatefile(statefile, oldPassphrase)
+		utils.ExplicitBzero(state)
+		if err != nil {
+			p.status = err.Error()
+			p.current.SetText("")
+			p.current.Focus()
+			return RedrawEvent{}
+		}
+		p.clear()
+
+		// halting the client waits for the StateWriter to finish writing
+		shutdownClient(p.a.c)
+		if err := rekeyStatefile(statefile, oldPassphrase, newPassphrase); errors.Is(err, errOldStatefileKept) {
+			return restartClient{status: "Passphrase changed, but " + err.Error()}
+		} else if err != nil {
+			return restartClient{status: "Passphrase not changed: " + err.Error()}
+		}
+		return restartClient{status: "Passphrase changed, enter the new passphrase"}
+	}
+	return nil
+}
+
+func (p *ChangePassphrasePage) Start(stop <-chan struct{}) {
+}
+
+func newChangePassphrasePage(a *App) *ChangePassphrasePage {
+	p := &ChangePassphrasePage{a: a,
+		back:    &widget.Clickable{},
+		submit:  &widget.Clickable{},
+		current: &widget.Editor{SingleLine: true, Mask: '*', Submit: true},
+		newPass: &widget.Editor{SingleLine: true, Mask: '*', Submit: true},
+		confirm: &widget.Editor{SingleLine: true, Mask: '*', Submit: true},
+	}
+	p.current.Focus()
+	return p
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/hako/durafmt"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/core/utils"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+const (
+	// defaultPandaLifetime is the number of days after which a pending key exchange is cancelled
+	defaultPandaLifetime = 7
+	maxPandaLifetime     = 30.0
+)
+
+var (
+	pendingList       = &layout.List{Axis: layout.Vertical}
+	showSecretIcon, _ = widget.NewIcon(icons.ActionVisibility)
+	restartIcon, _    = widget.NewIcon(icons.AVReplay)
+	removeIcon, _     = widget.NewIcon(icons.ActionDelete)
+)
+
+// pendingExchange describes an in-progress PANDA key exchange
+type pendingExchange struct {
+	nickname string
+	started  time.Time
+	lastErr  string
+}
+
+// pandaState returns the last PANDA error and shared secret of a contact,
+// which catshadow only exposes through the serialized contact.
+func pandaState(contact *catshadow.Contact) (string, []byte, error) {
+	b, err := contact.MarshalBinary()
+	if err != nil {
+		return "", nil, err
+	}
+	defer utils.ExplicitBzero(b)
+	s := struct {
+		PandaResult  string
+		SharedSecret []byte
+	}{}
+	if err := cbor.Unmarshal(b, &s); err != nil {
+		return "", nil, err
+	}
+	return s.PandaResult, s.SharedSecret, nil
+}
+
+// pandaStarted returns when the key exchange with a contact was started, or
+// the zero time if it was not recorded
+func pandaStarted(c *catshadow.Client, nickname string) time.Time {
+	var t time.Time
+	if b, err := c.GetBlob("pending://" + nickname); err == nil {
+		t.UnmarshalBinary(b)
+	}
+	return t
+}
+
+// recordPandaStarts considers the exchanges that were started before their
+// start time was recorded to start now. It runs once on unlock, so that
+// listing the exchanges never writes to the statefile.
+func recordPandaStarts(c *catshadow.Client) {
+	for nickname, contact := range c.GetContacts() {
+		if contact.IsPending && pandaStarted(c, nickname).IsZero() {
+			setPandaStarted(c, nickname, time.Now())
+		}
+	}
+}
+
+// setPandaStarted records the start time of a key exchange
+func setPandaStarted(c *catshadow.Client, nickname string, t time.Time) {
+	if b, err := t.MarshalBinary(); err == nil {
+		c.AddBlob("pending://"+nickname, b)
+	}
+}
+
+// pandaLifetime returns the configured lifetime of key exchanges, or 0 if they never expire
+func pandaLifetime(c *catshadow.Client) time.Duration {
+	days := defaultPandaLifetime
+	if b, err := c.GetBlob("PandaLifetime"); err == nil {
+		if d, err := strconv.Atoi(string(b)); err == nil {
+			days = d
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// getPendingExchanges returns the pending key exchanges, oldest first
+func getPendingExchanges(c *catshadow.Client) []*pendingExchange {
+	pending := make([]*pendingExchange, 0)
+	for nickname, contact := range c.GetContacts() {
+		if !contact.IsPending {
+			continue
+		}
+		e := &pendingExchange{nickname: nickname, started: pandaStarted(c, nickname)}
+		if result, secret, err := pandaState(contact); err == nil {
+			e.lastErr = result
+			utils.ExplicitBzero(secret)
+		}
+		pending = append(pending, e)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].started.Before(pending[j].started)
+	})
+	return pending
+}
+
+// hasPendingContacts returns true if any key exchange is in progress
+func hasPendingContacts(a *App) bool {
+	if a.c == nil {
+		return false
+	}
+	for _, contact := range a.c.GetContacts() {
+		if contact.IsPending {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelExchange removes a pending contact and its blobs
+func cancelExchange(c *catshadow.Client, nickname string) {
+	c.RemoveContact(nickname)
+	deleteContactBlobs(c, nickname)
+	delete(avatars, nickname)
+}
+
+// restartExchange discards the state of a pending key exchange and starts
+// a new one with the same nickname and shared secret
+func restartExchange(c *catshadow.Client, nickname string) error {
+	contact, ok := c.GetContacts()[nickname]
+	if !ok {
+		return catshadow.ErrContactNotFound
+	}
+	_, secret, err := pandaState(contact)
+	if err != nil {
+		return err
+	}
+	if len(secret) == 0 {
+		return fmt.Errorf("No shared secret stored for %s", nickname)
+	}
+	if err := c.RemoveContact(nickname); err != nil {
+		return err
+	}
+	c.NewContact(nickname, secret)
+	setPandaStarted(c, nickname, time.Now())
+	return nil
+}
+
+// expirePendingExchanges cancels the key exchanges older than the configured lifetime
+func (a *App) expirePendingExchanges() {
+	lifetime := pandaLifetime(a.c)
+	if lifetime == 0 {
+		return
+	}
+	for _, e := range getPendingExchanges(a.c) {
+		if !e.started.IsZero() && time.Since(e.started) > lifetime {
+			cancelExchange(a.c, e.nickname)
+			notifications.transient(notice{title: "Key Exchange",
+				full:    fmt.Sprintf("Expired: %s", e.nickname),
+				sender:  fmt.Sprintf("Expired: %s", e.nickname),
+				generic: "A key exchange expired",
+			})
+		}
+	}
+}
+
+// PendingPage lists the in-progress key exchanges
+type PendingPage struct {
+	a        *App
+	back     *widget.Clickable
+	show     map[string]*widget.Clickable
+	restart  map[string]*widget.Clickable
+	cancel   map[string]*widget.Clickable
+	errMsg   string
+	pending  []*pendingExchange
+	lifetime time.Duration
+}
+
+// ShowPendingClick is the event that requests the list of pending key exchanges
+type ShowPendingClick struct{}
+
+// ShowSecret is the event that requests the shared secret of a pending contact
+type ShowSecret struct {
+	nickname string
+}
+
+func (p *PendingPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Pending Key Exchanges").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				if p.errMsg == "" {
+					return layout.Dimensions{}
+				}
+				return inset.Layout(gtx, material.Body2(th, p.errMsg).Layout)
+			}),
+			layout.Flexed(1, func(gtx C) D {
+				if len(p.pending) == 0 {
+					return layout.Center.Layout(gtx, material.Body2(th, "No key exchanges in progress").Layout)
+				}
+				return pendingList.Layout(gtx, len(p.pending), func(gtx C, i int) D {
+					e := p.pending[i]
+					in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+					return in.Layout(gtx, func(gtx C) D {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+							layout.Rigid(func(gtx C) D {
+								return layoutAvatar(gtx, p.a.c, e.nickname)
+							}),
+							layout.Flexed(1, func(gtx C) D {
+								in := layout.Inset{Left: unit.Dp(12), Right: unit.Dp(12)}
+								return in.Layout(gtx, func(gtx C) D {
+									return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+										layout.Rigid(ContactStyle(th, e.nickname).Layout),
+										layout.Rigid(material.Caption(th, p.status(e)).Layout),
+										layout.Rigid(func(gtx C) D {
+											if e.lastErr == "" {
+												return layout.Dimensions{}
+											}
+											return material.Caption(th, "Last error: "+e.lastErr).Layout(gtx)
+										}),
+									)
+								})
+							}),
+							layout.Rigid(button(th, p.show[e.nickname], showSecretIcon, "Show secret of "+e.nickname).Layout),
+							layout.Rigid(button(th, p.restart[e.nickname], restartIcon, "Restart key exchange with "+e.nickname).Layout),
+							layout.Rigid(button(th, p.cancel[e.nickname], removeIcon, "Cancel key exchange with "+e.nickname).Layout),
+						)
+					})
+				})
+			}),
+		)
+	})
+}
+
+// status returns the age and expiry of a pending exchange
+func (p *PendingPage) status(e *pendingExchange) string {
+	age := time.Since(e.started).Truncate(time.Minute)
+	status := "Started " + strings.Replace(durafmt.ParseShort(age).Format(units), "0 s", "now", 1) + " ago"
+	if p.lifetime != 0 {
+		remaining := (p.lifetime - age).Truncate(time.Minute)
+		if remaining < 0 {
+			remaining = 0
+		}
+		status += ", expires in " + durafmt.ParseShort(remaining).Format(units)
+	}
+	return status
+}
+
+func (p *PendingPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	for _, e := range p.pending {
+		if p.show[e.nickname].Clicked() {
+			return ShowSecret{nickname: e.nickname}
+		}
+		if p.restart[e.nickname].Clicked() {
+			if err := restartExchange(p.a.c, e.nickname); err != nil {
+				p.errMsg = err.Error()
+			} else {
+				p.errMsg = ""
+			}
+			p.refresh()
+			return RedrawEvent{}
+		}
+		if p.cancel[e.nickname].Clicked() {
+			cancelExchange(p.a.c, e.nickname)
+			p.refresh()
+			return RedrawEvent{}
+		}
+	}
+	return nil
+}
+
+// refresh reloads the list of pending exchanges
+func (p *PendingPage) refresh() {
+	p.pending = getPendingExchanges(p.a.c)
+	p.lifetime = pandaLifetime(p.a.c)
+	for _, e := range p.pending {
+		if _, ok := p.show[e.nickname]; !ok {
+			p.show[e.nickname] = &widget.Clickable{}
+			p.restart[e.nickname] = &widget.Clickable{}
+			p.cancel[e.nickname] = &widget.Clickable{}
+		}
+	}
+}
+
+func (p *PendingPage) Start(stop <-chan struct{}) {
+	// refresh the page as exchanges progress
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(30 * time.Second):
+				p.a.w.Invalidate()
+			}
+		}
+	}()
+}
+
+func newPendingPage(a *App) *PendingPage {
+	p := &PendingPage{a: a,
+		back:    &widget.Clickable{},
+		show:    make(map[string]*widget.Clickable),
+		restart: make(map[string]*widget.Clickable),
+		cancel:  make(map[string]*widget.Clickable),
+	}
+	p.refresh()
+	return p
+}
+
+// SecretPage shows the shared secret of a pending contact so that it can be given again
+type SecretPage struct {
+	a         *App
+	nickname  string
+	contactal *Contactal
+	back      *widget.Clickable
+	copy      *widget.Clickable
+}
+
+func (p *SecretPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Secret for "+p.nickname).Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Flexed(1, func(gtx C) D {
+				if p.contactal == nil {
+					return layout.Center.Layout(gtx, material.Body2(th, "The secret is no longer stored").Layout)
+				}
+				return layout.Center.Layout(gtx, func(gtx C) D {
+					x := gtx.Constraints.Max.X
+					if y := gtx.Constraints.Max.Y; y < x {
+						x = y
+					}
+					gtx.Constraints = layout.Exact(gtx.Constraints.Constrain(image.Point{X: x, Y: x}))
+					qr, err := p.contactal.QR()
+					if err != nil {
+						return material.Caption(th, "QR").Layout(gtx)
+					}
+					qr.BackgroundColor = th.Bg
+					qr.ForegroundColor = th.Fg
+					return widget.Image{Fit: widget.ScaleDown, Src: paint.NewImageOp(qr.Image(x))}.Layout(gtx)
+				})
+			}),
+			layout.Rigid(func(gtx C) D {
+				if p.contactal == nil {
+					return layout.Dimensions{}
+				}
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, func(gtx C) D {
+						return inset.Layout(gtx, material.Body1(th, p.contactal.SharedSecret).Layout)
+					}),
+					layout.Rigid(button(th, p.copy, copyIcon, "Copy secret").Layout),
+				)
+			}),
+		)
+	})
+}
+
+func (p *SecretPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.copy.Clicked() && p.contactal != nil {
+		p.a.copySensitive(gtx, p.contactal.SharedSecret)
+	}
+	return nil
+}
+
+func (p *SecretPage) Start(stop <-chan struct{}) {
+}
+
+func newSecretPage(a *App, nickname string) *SecretPage {
+	p := &SecretPage{a: a, nickname: nickname, back: &widget.Clickable{}, copy: &widget.Clickable{}}
+	if contact, ok := a.c.GetContacts()[nickname]; ok {
+		if _, secret, err := pandaState(contact); err == nil && len(secret) > 0 {
+			p.contactal = &Contactal{SharedSecret: string(secret)}
+			utils.ExplicitBzero(secret)
+		}
+	}
+	return p
+}
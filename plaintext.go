@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/core/utils"
+)
+
+// shutdownClient stops the client and wipes the plaintexts of its messages.
+// Messages share the plaintext stored in the client state, which can only be
+// erased once the StateWriter has stopped, so that the erased messages are not
+// saved. The client must not be used afterwards.
+func shutdownClient(c *catshadow.Client) {
+	var plaintexts [][]byte
+	for nickname, contact := range c.GetContacts() {
+		if contact.LastMessage != nil {
+			plaintexts = append(plaintexts, contact.LastMessage.Plaintext)
+		}
+		for _, m := range c.GetSortedConversation(nickname) {
+			plaintexts = append(plaintexts, m.Plaintext)
+		}
+	}
+	// halting the client waits for the StateWriter to finish writing
+	c.Shutdown()
+	for _, p := range plaintexts {
+		utils.ExplicitBzero(p)
+	}
+}
+
+// messageTexts holds the text of the messages shown by a page, so that each
+// plaintext is copied into a string once rather than on every frame. Gio
+// caches the layout of the strings it renders, so they cannot be wiped; the
+// copies are released with the page.
+type messageTexts map[*catshadow.Message]string
+
+// text returns the text of msg
+func (t messageTexts) text(msg *catshadow.Message) string {
+	s, ok := t[msg]
+	if !ok {
+		s = string(msg.Plaintext)
+		t[msg] = s
+	}
+	return s
+}
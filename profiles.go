@@ -0,0 +1,326 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+const (
+	// defaultProfile uses the statefile and config given on the command line
+	defaultProfile    = "Default"
+	profilesDirName   = "profiles"
+	profileConfigName = "client.toml"
+)
+
+// currentProfile is the profile unlocked by the sign in page
+var currentProfile = defaultProfile
+
+var (
+	errProfileName   = errors.New("Profile names cannot be empty, start with a dot or contain slashes")
+	errProfileExists = errors.New("A profile with that name already exists")
+)
+
+// profileDir returns the directory holding the statefile and client config
+// of a profile, creating it if needed
+func profileDir(name string) (string, error) {
+	datadir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	if name == defaultProfile {
+		return datadir, nil
+	}
+	dir := filepath.Join(datadir, profilesDirName, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// profileConfigPath returns the client config to use with the current
+// profile, or "" to use the built in configuration. A config given with -f
+// applies to every profile.
+func profileConfigPath() string {
+	if len(*clientConfigFile) != 0 {
+		return *clientConfigFile
+	}
+	if currentProfile == defaultProfile {
+		return ""
+	}
+	dir, err := profileDir(currentProfile)
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, profileConfigName)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// listProfiles returns the default profile followed by the named profiles
+func listProfiles() []string {
+	profiles := []string{defaultProfile}
+	datadir, err := appDataDir()
+	if err != nil {
+		return profiles
+	}
+	entries, err := os.ReadDir(filepath.Join(datadir, profilesDirName))
+	if err != nil {
+		return profiles
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != defaultProfile {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return append(profiles, names...)
+}
+
+// checkProfileName returns an error if name cannot be used for a new profile
+func checkProfileName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:`) {
+		return errProfileName
+	}
+	for _, p := range listProfiles() {
+		if strings.EqualFold(p, name) {
+			return errProfileExists
+		}
+	}
+	return nil
+}
+
+// createProfile creates an empty profile. Its statefile is created when it
+// is first unlocked, with the passphrase entered then.
+func createProfile(name string) error {
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+	_, err := profileDir(name)
+	return err
+}
+
+// renameProfile renames a named profile
+func renameProfile(name, newName string) error {
+	if name == defaultProfile {
+		return errors.New("The default profile cannot be renamed")
+	}
+	if err := checkProfileName(newName); err != nil {
+		return err
+	}
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(dir, filepath.Join(filepath.Dir(dir), newName)); err != nil {
+		return err
+	}
+	if currentProfile == name {
+		currentProfile = newName
+	}
+	return nil
+}
+
+// deleteProfile shreds a named profile with its statefile and config
+func deleteProfile(name string) error {
+	if name == defaultProfile {
+		return errors.New("The default profile cannot be deleted")
+	}
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+	if err := shredDir(dir); err != nil {
+		return err
+	}
+	if currentProfile == name {
+		currentProfile = defaultProfile
+	}
+	return nil
+}
+
+// profileRow holds the widgets of one profile on the ProfilesPage
+type profileRow struct {
+	rename  *widget.Clickable
+	remove  *widget.Clickable
+	confirm *widget.Clickable
+}
+
+// ProfilesPage creates, renames and deletes profiles
+type ProfilesPage struct {
+	a        *App
+	back     *widget.Clickable
+	create   *widget.Clickable
+	name     *widget.Editor
+	list     *layout.List
+	profiles []string
+	rows     map[string]*profileRow
+	deleting string
+	status   string
+}
+
+// ShowProfilesClick is the event that requests the profiles page
+type ShowProfilesClick struct{}
+
+// ProfilesComplete is emitted when leaving the profiles page, so that the
+// sign in page lists the profiles again
+type ProfilesComplete struct{}
+
+// Layout returns the name field and a row for each profile
+func (p *ProfilesPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Profiles").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(th, "Each profile has its own statefile, passphrase, contacts and settings. A client config can be placed in a profile's directory as "+profileConfigName+".").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(func(gtx C) D {
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+								layout.Flexed(1, material.Editor(th, p.name, "Profile name").Layout),
+								layout.Rigid(material.Button(th, p.create, "Create").Layout),
+							)
+						}),
+						layout.Rigid(material.Body2(th, p.status).Layout),
+					)
+				})
+			}),
+			layout.Flexed(1, func(gtx C) D {
+				return p.list.Layout(gtx, len(p.profiles), func(gtx C, i int) D {
+					return inset.Layout(gtx, p.layoutProfile(p.profiles[i]))
+				})
+			}),
+		)
+	})
+}
+
+// layoutProfile returns the row of a profile, with its rename and delete buttons
+func (p *ProfilesPage) layoutProfile(name string) layout.Widget {
+	return func(gtx C) D {
+		row := p.rows[name]
+		if name == defaultProfile {
+			return material.Body1(th, name).Layout(gtx)
+		}
+		if p.deleting == name {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, material.Body2(th, "Delete "+name+" and its statefile?").Layout),
+				layout.Rigid(chip(th, row.confirm, "Delete", true)),
+				layout.Rigid(chip(th, row.remove, "Cancel", false)),
+			)
+		}
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, material.Body1(th, name).Layout),
+			layout.Rigid(chip(th, row.rename, "Rename", false)),
+			layout.Rigid(button(th, row.remove, removeIcon, "Delete "+name).Layout),
+		)
+	}
+}
+
+// refresh reads the list of profiles again
+func (p *ProfilesPage) refresh() {
+	p.profiles = listProfiles()
+	for _, name := range p.profiles {
+		if _, ok := p.rows[name]; !ok {
+			p.rows[name] = &profileRow{rename: &widget.Clickable{}, remove: &widget.Clickable{}, confirm: &widget.Clickable{}}
+		}
+	}
+}
+
+// Event handles creating, renaming and deleting profiles
+func (p *ProfilesPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return ProfilesComplete{}
+	}
+	for _, ev := range p.name.Events() {
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			p.create.Click()
+		}
+	}
+	if p.create.Clicked() {
+		name := strings.TrimSpace(p.name.Text())
+		if err := createProfile(name); err != nil {
+			p.status = err.Error()
+		} else {
+			p.status = fmt.Sprintf("Created %s, unlock it to choose its passphrase", name)
+			p.name.SetText("")
+			p.refresh()
+		}
+		return RedrawEvent{}
+	}
+	for _, name := range p.profiles {
+		row := p.rows[name]
+		if row.rename.Clicked() {
+			newName := strings.TrimSpace(p.name.Text())
+			if newName == "" {
+				p.status = "Enter the new name of " + name + " above"
+				p.name.Focus()
+			} else if err := renameProfile(name, newName); err != nil {
+				p.status = err.Error()
+			} else {
+				p.status = fmt.Sprintf("Renamed %s to %s", name, newName)
+				p.name.SetText("")
+				p.refresh()
+			}
+			return RedrawEvent{}
+		}
+		if row.remove.Clicked() {
+			if p.deleting == name {
+				p.deleting = ""
+			} else {
+				p.deleting = name
+			}
+			return RedrawEvent{}
+		}
+		if row.confirm.Clicked() {
+			p.deleting = ""
+			if err := deleteProfile(name); err != nil {
+				p.status = err.Error()
+			} else {
+				p.status = "Deleted " + name
+				p.refresh()
+			}
+			return RedrawEvent{}
+		}
+	}
+	return nil
+}
+
+func (p *ProfilesPage) Start(stop <-chan struct{}) {
+}
+
+func newProfilesPage(a *App) *ProfilesPage {
+	p := &ProfilesPage{a: a,
+		back:   &widget.Clickable{},
+		create: &widget.Clickable{},
+		name:   &widget.Editor{SingleLine: true, Submit: true},
+		list:   &layout.List{Axis: layout.Vertical},
+		rows:   make(map[string]*profileRow),
+	}
+	p.refresh()
+	return p
+}
@@ -26,7 +26,7 @@ func (p *RenameContactPage) Layout(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{Axis: layout.Vertical, Alignment: layout.End}.Layout(gtx,
 			layout.Rigid(func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Baseline}.Layout(gtx,
-					layout.Rigid(button(th, p.back, backIcon).Layout),
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					layout.Rigid(material.H6(th, "Rename Contact").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout))
@@ -53,6 +53,7 @@ func (p *RenameContactPage) Event(gtx layout.Context) interface{} {
 	if p.submit.Clicked() {
 		err := p.a.c.RenameContact(p.nickname, p.newnickname.Text())
 		if err == nil {
+			renameContactBlobs(p.a.c, p.nickname, p.newnickname.Text())
 			return EditContactComplete{}
 		}
 		p.newnickname.SetText("")
@@ -0,0 +1,138 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/katzenpost/katzen/assets"
+	"github.com/katzenpost/katzenpost/core/crypto/rand"
+)
+
+const (
+	// secretWords is the number of words in a generated spoken secret
+	secretWords = 6
+	// minSecretEntropy is the estimated strength in bits a shared secret
+	// must have before a key exchange is started with it
+	minSecretEntropy = 40.0
+	// goodSecretEntropy is the estimated strength above which a shared
+	// secret is considered strong
+	goodSecretEntropy = 60.0
+)
+
+var (
+	wordlist   = strings.Fields(assets.Wordlist)
+	wordlookup = func() map[string]bool {
+		m := make(map[string]bool)
+		for _, w := range wordlist {
+			m[w] = true
+		}
+		return m
+	}()
+)
+
+// newWordSecret returns n words chosen uniformly at random from the wordlist
+func newWordSecret(n int) string {
+	words := make([]string, n)
+	max := big.NewInt(int64(len(wordlist)))
+	for i := range words {
+		j, err := crand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		words[i] = wordlist[j.Int64()]
+	}
+	return strings.Join(words, " ")
+}
+
+// secretTokens splits a secret on whitespace and hyphens, which people
+// commonly use interchangeably when reading words aloud
+func secretTokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-'
+	})
+}
+
+// isWordSecret returns true if every token of the secret is in the wordlist
+func isWordSecret(s string) bool {
+	tokens := secretTokens(s)
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, t := range tokens {
+		if !wordlookup[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeSecret returns word secrets in a canonical form so that both
+// parties derive the same secret regardless of case and spacing. Other
+// secrets are returned unchanged.
+func normalizeSecret(s string) string {
+	if isWordSecret(s) {
+		return strings.Join(secretTokens(s), " ")
+	}
+	return s
+}
+
+// secretEntropy estimates the strength of a secret in bits. Distinct words
+// from the wordlist are counted as a uniform choice from the list and repeated
+// words add nothing, anything else is estimated from the character classes
+// used, discounting repeated and sequential characters.
+func secretEntropy(s string) float64 {
+	if isWordSecret(s) {
+		distinct := make(map[string]bool)
+		for _, t := range secretTokens(s) {
+			distinct[t] = true
+		}
+		return float64(len(distinct)) * math.Log2(float64(len(wordlist)))
+	}
+	var lower, upper, digit, symbol, other bool
+	var length float64
+	var prev rune
+	for i, r := range s {
+		switch {
+		case unicode.IsLower(r) && r < unicode.MaxASCII:
+			lower = true
+		case unicode.IsUpper(r) && r < unicode.MaxASCII:
+			upper = true
+		case unicode.IsDigit(r) && r < unicode.MaxASCII:
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+		// repeats and runs like "aaaa" or "1234" add very little
+		if i > 0 && (r == prev || r == prev+1 || r == prev-1) {
+			length += 0.25
+		} else {
+			length++
+		}
+		prev = r
+	}
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+	return length * math.Log2(float64(pool))
+}
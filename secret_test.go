@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeSecret(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"abbey able acid", "abbey able acid"},
+		{"  Abbey-ABLE\tacid ", "abbey able acid"},
+		{"abbey--able", "abbey able"},
+		// not every token is in the wordlist
+		{"Abbey able zzz", "Abbey able zzz"},
+		{"correct horse", "correct horse"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := normalizeSecret(test.s); got != test.want {
+			t.Errorf("normalizeSecret(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+}
+
+func TestSecretEntropy(t *testing.T) {
+	word := math.Log2(float64(len(wordlist)))
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"", 0},
+		{"abbey able acid acorn acre", 5 * word},
+		{"Abbey-Able-Acid", 3 * word},
+		// repeated words add nothing
+		{"abbey abbey abbey", word},
+		{"abcxyz", (1 + 0.25 + 0.25 + 1 + 0.25 + 0.25) * math.Log2(26)},
+		{"aaaa", (1 + 3*0.25) * math.Log2(26)},
+		{"1234", (1 + 3*0.25) * math.Log2(10)},
+		{"Tr0ub4dor&3", 11 * math.Log2(26+26+10+33)},
+		{"мир", 3 * math.Log2(100)},
+	}
+	for _, test := range tests {
+		if got := secretEntropy(test.s); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("secretEntropy(%q) = %.2f, want %.2f", test.s, got, test.want)
+		}
+	}
+	if got := secretEntropy(newWordSecret(secretWords)); got < minSecretEntropy {
+		t.Errorf("a generated secret is estimated at %.2f bits, below %v", got, minSecretEntropy)
+	}
+}
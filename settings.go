@@ -1,13 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
-	"gioui.org/x/notify"
 )
 
 // SettingsPage is for user settings
@@ -17,6 +20,29 @@ type SettingsPage struct {
 	submit            *widget.Clickable
 	switchUseTor      *widget.Bool
 	switchAutoConnect *widget.Bool
+	pandaLifetime     *widget.Float
+	switchQuietHours  *widget.Bool
+	quietStart        *widget.Float
+	quietEnd          *widget.Float
+	privacy           *widget.Enum
+	settings          *layout.List
+	unblock           map[string]*widget.Clickable
+	addressBook       *widget.Clickable
+	backup            *widget.Clickable
+	passphrase        *widget.Clickable
+	switchProfile     *widget.Clickable
+	duress            *widget.Clickable
+	wipe              *widget.Clickable
+	wipeAfter         *widget.Float
+	clipboardTimeout  *widget.Float
+	autoLock          *widget.Float
+	switchLockOnPause *widget.Bool
+	theme             *themeEditor
+	textSize          *widget.Float
+	uiScale           *widget.Float
+	keys              *widget.Clickable
+	// unsaved are the sliders whose value is saved when they are let go
+	unsaved map[*widget.Float]bool
 }
 
 var (
@@ -30,6 +56,7 @@ const (
 
 // Layout returns a simple centered layout prompting to update settings
 func (p *SettingsPage) Layout(gtx layout.Context) layout.Dimensions {
+	defer p.checkReleased(gtx)
 	bg := Background{
 		Color: th.Bg,
 		Inset: layout.Inset{},
@@ -40,30 +67,16 @@ func (p *SettingsPage) Layout(gtx layout.Context) layout.Dimensions {
 			// topbar
 			layout.Rigid(func(gtx C) D {
 				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Baseline}.Layout(gtx,
-					layout.Rigid(button(th, p.back, backIcon).Layout),
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					layout.Rigid(material.H6(th, "Settings").Layout),
 					layout.Flexed(1, fill{th.Bg}.Layout))
 			}),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
-					layout.Flexed(settingNameColumnWidth, func(gtx C) D {
-						return inset.Layout(gtx, material.Body1(th, "Use Tor").Layout)
-					}),
-					layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
-						return inset.Layout(gtx, material.Switch(th, p.switchUseTor, "Use Tor").Layout)
-					}),
-				)
-			}),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
-					layout.Flexed(settingNameColumnWidth, func(gtx C) D {
-						return inset.Layout(gtx, material.Body1(th, "Connect Automatically").Layout)
-					}),
-					layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
-						return inset.Layout(gtx, material.Switch(th, p.switchAutoConnect, "Connect Automatically").Layout)
-					}),
-				)
+			layout.Flexed(1, func(gtx C) D {
+				rows := p.rows()
+				return p.settings.Layout(gtx, len(rows), func(gtx C, i int) D {
+					return rows[i](gtx)
+				})
 			}),
 			layout.Rigid(func(gtx C) D {
 				return material.Button(th, p.submit, "Apply Settings").Layout(gtx)
@@ -72,13 +85,345 @@ func (p *SettingsPage) Layout(gtx layout.Context) layout.Dimensions {
 	})
 }
 
-type restartClient struct{}
+// rows returns the settings shown in the scrollable list
+func (p *SettingsPage) rows() []layout.Widget {
+	rows := append(p.theme.rows(),
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Text Size").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Slider(th, p.textSize, minTextSize, maxTextSize).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, fmt.Sprintf("%d sp", int(p.textSize.Value+.5))).Layout)
+						}),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Interface Scale").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Slider(th, p.uiScale, minUIScale, maxUIScale).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, fmt.Sprintf("%d%%", int(p.uiScale.Value/10+.5)*10)).Layout)
+						}),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Keyboard").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Button(th, p.keys, "Keyboard Shortcuts").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Use Tor").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Switch(th, p.switchUseTor, "Use Tor").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Connect Automatically").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Switch(th, p.switchAutoConnect, "Connect Automatically").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			days := int(p.pandaLifetime.Value + .5)
+			desc := "Never"
+			if days > 0 {
+				desc = fmt.Sprintf("%d days", days)
+			}
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Key Exchange Expires").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Slider(th, p.pandaLifetime, 0, maxPandaLifetime).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, desc).Layout)
+						}),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			minutes := int(p.autoLock.Value + .5)
+			desc := "Never"
+			if minutes > 0 {
+				desc = fmt.Sprintf("%d min idle", minutes)
+			}
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Lock Automatically").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Slider(th, p.autoLock, 0, maxAutoLockMinutes).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, desc).Layout)
+						}),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Lock in Background").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Switch(th, p.switchLockOnPause, "Lock in Background").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			seconds := int(p.clipboardTimeout.Value + .5)
+			desc := "Never"
+			if seconds > 0 {
+				desc = fmt.Sprintf("%d s", seconds)
+			}
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Clear Clipboard").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Slider(th, p.clipboardTimeout, 0, maxClipboardTimeout).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, desc).Layout)
+						}),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Quiet Hours").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Switch(th, p.switchQuietHours, "Quiet Hours").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			if !p.switchQuietHours.Value {
+				return layout.Dimensions{}
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(hourSlider(p.quietStart, "From")),
+				layout.Rigid(hourSlider(p.quietEnd, "Until")),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Notifications Show").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(material.RadioButton(th, p.privacy, privacyFull, "Sender and message").Layout),
+						layout.Rigid(material.RadioButton(th, p.privacy, privacySender, "Sender").Layout),
+						layout.Rigid(material.RadioButton(th, p.privacy, privacyGeneric, "Nothing").Layout),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Contacts").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Button(th, p.addressBook, "Export or Import Address Book").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Backup").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Button(th, p.backup, "Back up Statefile").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Passphrase").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Button(th, p.passphrase, "Change Passphrase").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Duress").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Button(th, p.duress, "Duress Passphrase").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Emergency").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Button(th, p.wipe, "Wipe Everything").Layout)
+				}),
+			)
+		},
+		func(gtx C) D {
+			failures := int(p.wipeAfter.Value + .5)
+			desc := "Never"
+			if failures > 0 {
+				desc = fmt.Sprintf("%d failures", failures)
+			}
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Destroy Statefile After").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Slider(th, p.wipeAfter, 0, maxWipeAfter).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, desc).Layout)
+						}),
+					)
+				}),
+			)
+		},
+		func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body1(th, "Profile").Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, currentProfile).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Button(th, p.switchProfile, "Switch Profile").Layout)
+						}),
+					)
+				}),
+			)
+		},
+	)
+	return append(rows, p.blockedRows()...)
+}
+
+// blockedRows returns the list of blocked contacts, each with a button to unblock it
+func (p *SettingsPage) blockedRows() []layout.Widget {
+	blocked := make([]string, 0)
+	for nickname := range p.a.c.GetContacts() {
+		if isBlocked(p.a.c, nickname) {
+			blocked = append(blocked, nickname)
+		}
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+	sort.Strings(blocked)
+	rows := []layout.Widget{func(gtx C) D {
+		return inset.Layout(gtx, material.Body1(th, "Blocked").Layout)
+	}}
+	for _, nickname := range blocked {
+		nickname := nickname
+		if _, ok := p.unblock[nickname]; !ok {
+			p.unblock[nickname] = &widget.Clickable{}
+		}
+		rows = append(rows, func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, func(gtx C) D {
+						return layoutAvatar(gtx, p.a.c, nickname)
+					})
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Body2(th, nickname).Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, material.Button(th, p.unblock[nickname], "Unblock").Layout)
+						}),
+					)
+				}),
+			)
+		})
+	}
+	return rows
+}
+
+// restartClient returns to the sign in page, showing status if set
+type restartClient struct {
+	status string
+}
 
 // Event catches the widget submit events and calls Settings
 func (p *SettingsPage) Event(gtx layout.Context) interface{} {
 	if p.back.Clicked() {
 		return BackEvent{}
 	}
+	p.theme.event()
+	// the text size and interface scale apply while sliding, and are saved once let go
+	textSizeSettled := p.settled(p.textSize)
+	if textSizeSettled || p.unsaved[p.textSize] {
+		p.a.setTextSize(int(p.textSize.Value + .5))
+	}
+	if textSizeSettled {
+		p.a.saveTextSize()
+	}
+	uiScaleSettled := p.settled(p.uiScale)
+	if uiScaleSettled || p.unsaved[p.uiScale] {
+		// scale in steps of 10%, so the page does not jump around while sliding
+		p.a.setUIScale(int(p.uiScale.Value/10+.5) * 10)
+	}
+	if uiScaleSettled {
+		p.a.saveUIScale()
+	}
 	if p.switchUseTor.Changed() {
 		if p.switchUseTor.Value && !hasTor() {
 			p.switchUseTor.Value = false
@@ -99,19 +444,108 @@ func (p *SettingsPage) Event(gtx layout.Context) interface{} {
 			p.a.c.DeleteBlob("AutoConnect")
 		}
 	}
+	if p.settled(p.pandaLifetime) {
+		days := int(p.pandaLifetime.Value + .5)
+		p.a.c.AddBlob("PandaLifetime", []byte(strconv.Itoa(days)))
+	}
+	if p.settled(p.autoLock) {
+		minutes := int(p.autoLock.Value + .5)
+		p.a.c.AddBlob("AutoLockMinutes", []byte(strconv.Itoa(minutes)))
+	}
+	if p.switchLockOnPause.Changed() {
+		if p.switchLockOnPause.Value {
+			p.a.c.AddBlob("LockOnPause", []byte{1})
+		} else {
+			p.a.c.DeleteBlob("LockOnPause")
+		}
+	}
+	if p.settled(p.clipboardTimeout) {
+		p.a.c.AddBlob("ClipboardTimeout", []byte(strconv.Itoa(int(p.clipboardTimeout.Value+.5))))
+	}
+	if p.switchQuietHours.Changed() {
+		if p.switchQuietHours.Value {
+			p.a.c.AddBlob("QuietHours", []byte{1})
+		} else {
+			p.a.c.DeleteBlob("QuietHours")
+		}
+	}
+	if p.settled(p.quietStart) {
+		p.a.c.AddBlob("QuietHoursStart", []byte(strconv.Itoa(int(p.quietStart.Value+.5))))
+	}
+	if p.settled(p.quietEnd) {
+		p.a.c.AddBlob("QuietHoursEnd", []byte(strconv.Itoa(int(p.quietEnd.Value+.5))))
+	}
+	for nickname, click := range p.unblock {
+		if click.Clicked() {
+			setFlag(p.a.c, blockedFlag, nickname, false)
+			delete(p.unblock, nickname)
+			return RedrawEvent{}
+		}
+	}
+	if p.keys.Clicked() {
+		return ShowKeysClick{}
+	}
+	if p.addressBook.Clicked() {
+		return ShowAddressBookClick{}
+	}
+	if p.backup.Clicked() {
+		return ShowBackupClick{}
+	}
+	if p.passphrase.Clicked() {
+		return ShowChangePassphraseClick{}
+	}
+	if p.settled(p.wipeAfter) {
+		u := loadAttempts()
+		u.WipeAfter = int(p.wipeAfter.Value + .5)
+		u.save()
+	}
+	if p.wipe.Clicked() {
+		return ShowWipeClick{}
+	}
+	if p.duress.Clicked() {
+		return ShowDuressClick{}
+	}
+	if p.switchProfile.Clicked() {
+		shutdownClient(p.a.c)
+		return restartClient{}
+	}
+	if p.privacy.Changed() {
+		p.a.c.AddBlob("NotificationPrivacy", []byte(p.privacy.Value))
+	}
 	if p.submit.Clicked() {
-		go func() {
-			if n, err := notify.Push("Restarting", "Katzen is restarting"); err == nil {
-				<-time.After(notificationTimeout)
-				n.Cancel()
-			}
-		}()
-		p.a.c.Shutdown()
+		notifications.transient(plainNotice("Restarting", "Katzen is restarting"))
+		shutdownClient(p.a.c)
 		return restartClient{}
 	}
 	return nil
 }
 
+// settled returns true once the value of the slider f has changed and the
+// slider was let go. Saving a setting re-encrypts the statefile, so a slider
+// is saved once rather than on every frame of a drag.
+func (p *SettingsPage) settled(f *widget.Float) bool {
+	if f.Changed() {
+		p.unsaved[f] = true
+	}
+	if p.unsaved[f] && !f.Dragging() {
+		delete(p.unsaved, f)
+		return true
+	}
+	return false
+}
+
+// checkReleased asks for one more frame when a slider with an unsaved value
+// was let go during this layout, as widget.Float learns of the release in
+// Layout, after Event has run
+func (p *SettingsPage) checkReleased(gtx layout.Context) {
+	for f := range p.unsaved {
+		if !f.Dragging() {
+			op.InvalidateOp{}.Add(gtx.Ops)
+			return
+		}
+	}
+}
+
 func (p *SettingsPage) Start(stop <-chan struct{}) {
 }
 
@@ -119,6 +553,20 @@ func newSettingsPage(a *App) *SettingsPage {
 	p := &SettingsPage{a: a}
 	p.back = &widget.Clickable{}
 	p.submit = &widget.Clickable{}
+	p.settings = &layout.List{Axis: layout.Vertical}
+	p.unblock = make(map[string]*widget.Clickable)
+	p.unsaved = make(map[*widget.Float]bool)
+	p.addressBook = &widget.Clickable{}
+	p.backup = &widget.Clickable{}
+	p.passphrase = &widget.Clickable{}
+	p.switchProfile = &widget.Clickable{}
+	p.duress = &widget.Clickable{}
+	p.wipe = &widget.Clickable{}
+	p.theme = newThemeEditor(a)
+	p.keys = &widget.Clickable{}
+	p.textSize = &widget.Float{Value: float32(display.textSize)}
+	p.uiScale = &widget.Float{Value: float32(display.uiScale)}
+	p.wipeAfter = &widget.Float{Value: float32(loadAttempts().WipeAfter)}
 	if _, err := a.c.GetBlob("UseTor"); err == nil {
 		p.switchUseTor = &widget.Bool{Value: true}
 	} else {
@@ -129,14 +577,39 @@ func newSettingsPage(a *App) *SettingsPage {
 	} else {
 		p.switchAutoConnect = &widget.Bool{Value: false}
 	}
+	p.pandaLifetime = &widget.Float{Value: float32(pandaLifetime(a.c) / (24 * time.Hour))}
+	p.autoLock = &widget.Float{Value: float32(autoLockTimeout(a.c) / time.Minute)}
+	p.switchLockOnPause = &widget.Bool{Value: lockOnPause(a.c)}
+	p.clipboardTimeout = &widget.Float{Value: float32(clipboardTimeout(a.c) / time.Second)}
+	start, end, enabled := quietHours(a.c)
+	p.switchQuietHours = &widget.Bool{Value: enabled}
+	p.quietStart = &widget.Float{Value: float32(start)}
+	p.quietEnd = &widget.Float{Value: float32(end)}
+	p.privacy = &widget.Enum{Value: notificationPrivacy(a.c)}
 	return p
 }
 
+// hourSlider returns a settings row for choosing an hour of the day
+func hourSlider(f *widget.Float, label string) layout.Widget {
+	return func(gtx C) D {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+				return inset.Layout(gtx, material.Body2(th, label).Layout)
+			}),
+			layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, func(gtx C) D {
+						return inset.Layout(gtx, material.Slider(th, f, 0, 23).Layout)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return inset.Layout(gtx, material.Body2(th, fmt.Sprintf("%02d:00", int(f.Value+.5))).Layout)
+					}),
+				)
+			}),
+		)
+	}
+}
+
 func warnNoTor() {
-	go func() {
-		if n, err := notify.Push("Failure", "Tor requested, but not available on port 9050. Disable in settings to connect."); err == nil {
-			<-time.After(notificationTimeout)
-			n.Cancel()
-		}
-	}()
+	notifications.transient(plainNotice("Failure", "Tor requested, but not available on port 9050. Disable in settings to connect."))
 }
@@ -1,11 +1,12 @@
 package main
 
 import (
-	"errors"
+	"fmt"
 	"net"
 	"os"
 
 	"gioui.org/app"
+	"github.com/awnumar/memguard"
 	"github.com/katzenpost/katzenpost/catshadow"
 	"github.com/katzenpost/katzenpost/client"
 	"github.com/katzenpost/katzenpost/client/config"
@@ -23,54 +24,87 @@ func hasTor() bool {
 	return true
 }
 
-func setupCatShadow(passphrase []byte, result chan interface{}) {
-	// XXX: if the catshadowClient already exists, shut it down
-	// FIXME: figure out a better way to toggle connected/disconnected
-	// states and allow to retry attempts on a timeout or other failure.
-	var catshadowClient *catshadow.Client
-	var stateWorker *catshadow.StateWriter
-	var state *catshadow.State
-	var err error
-
+// appDataDir returns the application data directory, creating it if needed
+func appDataDir() (string, error) {
 	// obtain the default data location
 	dir, err := app.DataDir()
 	if err != nil {
-		result <- err
-		return
+		return "", err
 	}
 
 	// dir does not appear to point to ~/.config/katzen but rather ~/.config on linux?
 	// create directory for application data
 	datadir := filepath.Join(dir, dataDirName)
-	_, err = os.Stat(datadir)
-	if os.IsNotExist(err) {
+	if _, err := os.Stat(datadir); os.IsNotExist(err) {
 		// create the application data directory
-		err := os.Mkdir(datadir, os.ModeDir|os.FileMode(0700))
-		if err != nil {
-			result <- err
-			return
+		if err := os.Mkdir(datadir, os.ModeDir|os.FileMode(0700)); err != nil {
+			return "", err
 		}
 	}
+	return datadir, nil
+}
+
+// statefilePath returns the statefile of the current profile. The default
+// profile uses the path given by the -s flag, relative to the application
+// data directory unless it exists as given.
+func statefilePath() (string, error) {
+	datadir, err := profileDir(currentProfile)
+	if err != nil {
+		return "", err
+	}
+	if currentProfile != defaultProfile {
+		return filepath.Join(datadir, filepath.Base(*stateFile)), nil
+	}
 
 	// if the statefile doesn't exist, try the default datadir
-	var statefile string
 	if _, err := os.Stat(*stateFile); os.IsNotExist(err) {
-		statefile = filepath.Join(datadir, *stateFile)
-	} else {
-		statefile = *stateFile
+		return filepath.Join(datadir, *stateFile), nil
+	}
+	return *stateFile, nil
+}
+
+// defaultSettings returns the settings blobs of a new statefile
+func defaultSettings(configFile string) map[string][]byte {
+	blob := make(map[string][]byte)
+	if hasTor() && len(configFile) == 0 {
+		blob["UseTor"] = []byte{1}
+		blob["AutoConnect"] = []byte{1}
+	}
+	return blob
+}
+
+// setupCatShadow unlocks the statefile of the current profile and starts a
+// client. The passphrase is destroyed as soon as the key has been derived.
+func setupCatShadow(passphrase *memguard.LockedBuffer, result chan interface{}) {
+	defer passphrase.Destroy()
+
+	// XXX: if the catshadowClient already exists, shut it down
+	// FIXME: figure out a better way to toggle connected/disconnected
+	// states and allow to retry attempts on a timeout or other failure.
+	var catshadowClient *catshadow.Client
+	var stateWorker *catshadow.StateWriter
+	var state *catshadow.State
+	var err error
+	var decoy bool
+
+	statefile, err := statefilePath()
+	if err != nil {
+		result <- err
+		return
 	}
 
 	var cfg *config.Config
-	if len(*clientConfigFile) != 0 {
-		cfg, err = config.LoadFile(*clientConfigFile)
+	configFile := profileConfigPath()
+	if len(configFile) != 0 {
+		cfg, err = config.LoadFile(configFile)
 		if err != nil {
-			result <- err
+			result <- fmt.Errorf("%w: %v", errConfig, err)
 			return
 		}
 	} else {
 		cfg, err = config.Load(cfgWithoutTor)
 		if err != nil {
-			result <- err
+			result <- fmt.Errorf("%w: %v", errConfig, err)
 			return
 		}
 	}
@@ -85,14 +119,29 @@ func setupCatShadow(passphrase []byte, result chan interface{}) {
 	// automatically create a statefile if one does not already exist
 	stateLogger := backendLog.GetLogger("catshadow_state")
 	if _, err = os.Stat(statefile); os.IsNotExist(err) {
-		stateWorker, err = catshadow.NewStateWriter(stateLogger, statefile, passphrase)
+		stateWorker, err = catshadow.NewStateWriter(stateLogger, statefile, passphrase.Bytes())
+	} else if !validStatefileSize(statefile) {
+		err = errCorruptStatefile
 	} else {
-		stateWorker, state, err = catshadow.LoadStateWriter(stateLogger, statefile, passphrase)
+		stateWorker, state, err = catshadow.LoadStateWriter(stateLogger, statefile, passphrase.Bytes())
+		if err == catshadow.DecryptStateFailed && validStatefileSize(duressSlot(statefile)) {
+			// the passphrase may be the duress passphrase, which opens the decoy
+			if w, s, derr := catshadow.LoadStateWriter(stateLogger, duressSlot(statefile), passphrase.Bytes()); derr == nil {
+				stateWorker, state, err = w, s, nil
+				decoy = true
+				if _, ok := state.Blob[duressWipeBlob]; ok {
+					destroyStatefile(statefile)
+				}
+				statefile = duressSlot(statefile)
+			}
+		}
 	}
+	// the StateWriter keeps the derived key, not the passphrase
+	passphrase.Destroy()
 
 	// catches any err above
 	if err != nil {
-		result <- err
+		result <- statefileError(err)
 		return
 	}
 
@@ -107,20 +156,17 @@ func setupCatShadow(passphrase []byte, result chan interface{}) {
 
 	// initialize default options
 	if state.Blob == nil {
-		state.Blob = make(map[string][]byte)
-		if hasTor() && len(*clientConfigFile) == 0 {
-			state.Blob["UseTor"] = []byte{1}
-			state.Blob["AutoConnect"] = []byte{1}
-		}
+		state.Blob = defaultSettings(configFile)
 	}
+	purgeDroppedMessages(state)
 
 	// apply any persistent settings that are needed before bootstrapping client
 	if _, ok := state.Blob["UseTor"]; ok {
-		if len(*clientConfigFile) != 0 {
+		if len(configFile) != 0 {
 			// a user-supplied configuration file was specified
 			if cfg.UpstreamProxy.Type != "socks5" {
 				state.Blob["UseTor"] = []byte{0}
-				result <- errors.New("User supplied configuration and client settings mismatch! UseTor option selected without valid UpstreamProxy!")
+				result <- fmt.Errorf("%w: User supplied configuration and client settings mismatch! UseTor option selected without valid UpstreamProxy!", errConfig)
 				return
 			}
 		}
@@ -131,7 +177,7 @@ func setupCatShadow(passphrase []byte, result chan interface{}) {
 		} else {
 			cfg, err = config.Load(cfgWithTor)
 			if err != nil {
-				result <- err
+				result <- fmt.Errorf("%w: %v", errConfig, err)
 				return
 			}
 		}
@@ -154,5 +200,12 @@ func setupCatShadow(passphrase []byte, result chan interface{}) {
 		stateWorker.Halt()
 		return
 	}
+	if decoy {
+		// saves the decoy without the wipe request
+		catshadowClient.DeleteBlob(duressWipeBlob)
+	} else {
+		ensureDuressSlot(statefile)
+	}
+	activeStatefile = statefile
 	result <- catshadowClient
 }
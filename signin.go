@@ -2,19 +2,38 @@ package main
 
 import (
 	"fmt"
+	"gioui.org/io/key"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"github.com/awnumar/memguard"
+	"io"
 	"runtime"
+	"time"
+	"unicode/utf8"
 )
 
 type signInPage struct {
-	a          *App
-	password   *widget.Editor
-	submit     *widget.Clickable
-	result     chan interface{}
-	errMsg     string
-	connecting bool
+	a           *App
+	password    *widget.Editor
+	submit      *widget.Clickable
+	restore     *widget.Clickable
+	manage      *widget.Clickable
+	profiles    []string
+	profile     map[string]*widget.Clickable
+	profileList *layout.List
+	result      chan interface{}
+	errMsg      string
+	attempts    *unlockAttempts
+	connecting  bool
+}
+
+func (p *signInPage) keyScopes() []string {
+	if *wipeKeys {
+		return []string{scopeSignIn}
+	}
+	return nil
 }
 
 func (p *signInPage) Start(stop <-chan struct{}) {
@@ -22,6 +41,9 @@ func (p *signInPage) Start(stop <-chan struct{}) {
 
 func (p *signInPage) Layout(gtx layout.Context) layout.Dimensions {
 	p.password.Focus()
+	if *wipeKeys {
+		key.InputOp{Tag: p, Keys: keymap.set(scopeSignIn)}.Add(gtx.Ops)
+	}
 	bg := Background{
 		Color: th.Bg,
 		Inset: layout.Inset{},
@@ -29,18 +51,48 @@ func (p *signInPage) Layout(gtx layout.Context) layout.Dimensions {
 	return bg.Layout(gtx, func(gtx C) D {
 		return layout.Flex{Axis: layout.Vertical, Spacing: layout.SpaceBetween, Alignment: layout.End}.Layout(gtx,
 			layout.Flexed(1, func(gtx C) D {
+				if wait := p.attempts.wait(); wait > 0 {
+					// redraw each second to count down
+					op.InvalidateOp{At: gtx.Now.Add(time.Second)}.Add(gtx.Ops)
+					hint := fmt.Sprintf("Too many attempts, try again in %s", wait.Round(time.Second))
+					return layout.Center.Layout(gtx, material.Editor(th, p.password, hint).Layout)
+				}
 				if p.errMsg != "" {
 					return layout.Center.Layout(gtx, material.Editor(th, p.password, p.errMsg).Layout)
 				}
-				return layout.Center.Layout(gtx, material.Editor(th, p.password, "Enter your password").Layout)
+				hint := "Enter your password"
+				if currentProfile != defaultProfile {
+					hint = "Enter the password of " + currentProfile
+				}
+				return layout.Center.Layout(gtx, material.Editor(th, p.password, hint).Layout)
 			}),
+			layout.Rigid(p.layoutProfiles),
 			layout.Rigid(func(gtx C) D {
+				return layout.Center.Layout(gtx, chip(th, p.restore, "Restore from backup", false))
+			}),
+			layout.Rigid(func(gtx C) D {
+				if p.attempts.wait() > 0 {
+					gtx = gtx.Disabled()
+				}
 				return material.Button(th, p.submit, "MEOW").Layout(gtx)
 			}),
 		)
 	})
 }
 
+// layoutProfiles returns the profile chooser
+func (p *signInPage) layoutProfiles(gtx C) D {
+	return layout.Center.Layout(gtx, func(gtx C) D {
+		return p.profileList.Layout(gtx, len(p.profiles)+1, func(gtx C, i int) D {
+			if i == len(p.profiles) {
+				return chip(th, p.manage, "Profiles…", false)(gtx)
+			}
+			name := p.profiles[i]
+			return chip(th, p.profile[name], name, name == currentProfile)(gtx)
+		})
+	})
+}
+
 type signInStarted struct {
 	result chan interface{}
 }
@@ -53,15 +105,41 @@ func (p *signInPage) Event(gtx layout.Context) interface{} {
 		}
 	}
 
+	for _, e := range gtx.Events(p) {
+		if e, ok := e.(key.Event); ok && keymap.action(scopeSignIn, e) == actionPanicWipe {
+			return ShowWipeClick{}
+		}
+	}
+
+	if p.manage.Clicked() {
+		return ShowProfilesClick{}
+	}
+	for name, click := range p.profile {
+		if click.Clicked() {
+			currentProfile = name
+			p.errMsg = ""
+			p.attempts = loadAttempts()
+			return RedrawEvent{}
+		}
+	}
+
+	if p.restore.Clicked() {
+		return ShowRestoreClick{}
+	}
+
 	if p.submit.Clicked() {
 		p.connecting = true
-		pw := p.password.Text()
-		p.password.SetText("")
-		if len(pw) != 0 && len(pw) < minPasswordLen {
+		pw := readPassphrase(p.password)
+		if p.attempts.wait() > 0 {
+			pw.Destroy()
+			return nil
+		}
+		if pw.Size() != 0 && pw.Size() < minPasswordLen {
+			pw.Destroy()
 			p.errMsg = fmt.Sprintf("Password must be minimum %d characters long", minPasswordLen)
 		} else {
 			go func() {
-				setupCatShadow([]byte(pw), p.result)
+				setupCatShadow(pw, p.result)
 				p.a.w.Invalidate()
 			}()
 			return signInStarted{result: p.result}
@@ -70,6 +148,19 @@ func (p *signInPage) Event(gtx layout.Context) interface{} {
 	return nil
 }
 
+// readPassphrase moves the contents of e into locked memory and clears e.
+// The bytes are read from the editor buffer rather than through Text, so no
+// string copy is made, and the read buffer is wiped by memguard. The editor
+// buffer and its undo history are released without being wiped, as gio
+// offers no way to clear them.
+func readPassphrase(e *widget.Editor) *memguard.LockedBuffer {
+	b := make([]byte, e.Len()*utf8.UTFMax)
+	e.Seek(0, io.SeekStart)
+	n, _ := io.ReadFull(e, b)
+	e.SetText("")
+	return memguard.NewBufferFromBytes(b[:n])
+}
+
 func newSignInPage(a *App) *signInPage {
 	pw := &widget.Editor{SingleLine: true, Mask: '*', Submit: true}
 
@@ -77,10 +168,24 @@ func newSignInPage(a *App) *signInPage {
 		pw.Submit = false
 	}
 
-	return &signInPage{
-		a:        a,
-		password: pw,
-		submit:   &widget.Clickable{},
-		result:   make(chan interface{}, 1),
+	p := &signInPage{
+		a:           a,
+		password:    pw,
+		submit:      &widget.Clickable{},
+		restore:     &widget.Clickable{},
+		manage:      &widget.Clickable{},
+		profiles:    listProfiles(),
+		profile:     make(map[string]*widget.Clickable),
+		profileList: &layout.List{Axis: layout.Horizontal},
+		result:      make(chan interface{}, 1),
+	}
+	for _, name := range p.profiles {
+		p.profile[name] = &widget.Clickable{}
+	}
+	// the profile may have been deleted or renamed
+	if _, ok := p.profile[currentProfile]; !ok {
+		currentProfile = defaultProfile
 	}
+	p.attempts = loadAttempts()
+	return p
 }
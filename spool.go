@@ -2,29 +2,25 @@ package main
 
 import (
 	"gioui.org/layout"
-	"time"
-	"gioui.org/op/clip"
-	"gioui.org/x/notify"
-	"gioui.org/widget"
-	"sync"
-	"image"
-	"gioui.org/gesture"
 	"gioui.org/unit"
+	"gioui.org/widget"
 	"gioui.org/widget/material"
 	"github.com/katzenpost/katzenpost/catshadow"
+	"sync"
+	"time"
 	//"gioui.org/widget/material"
 )
 
 type SpoolPage struct {
-	a        *App
-	provider *layout.List
-	providerClicks map[string]*gesture.Click
-	connect  *widget.Clickable
-	settings *widget.Clickable
-	back     *widget.Clickable
-	submit   *widget.Clickable
-	once     *sync.Once
-	errCh    chan error
+	a              *App
+	provider       *layout.List
+	providerClicks map[string]*widget.Clickable
+	connect        *widget.Clickable
+	settings       *widget.Clickable
+	back           *widget.Clickable
+	submit         *widget.Clickable
+	once           *sync.Once
+	errCh          chan error
 }
 
 func (p *SpoolPage) Start(stop <-chan struct{}) {
@@ -59,12 +55,12 @@ func (p *SpoolPage) Layout(gtx layout.Context) layout.Dimensions {
 					layout.Flexed(1, fill{th.Bg}.Layout),
 					func() layout.FlexChild {
 						if isConnected {
-							return layout.Rigid(button(th, p.connect, connectIcon).Layout)
+							return layout.Rigid(button(th, p.connect, connectIcon, "Disconnect").Layout)
 						}
-						return layout.Rigid(button(th, p.connect, disconnectIcon).Layout)
+						return layout.Rigid(button(th, p.connect, disconnectIcon, "Connect").Layout)
 					}(),
-					layout.Rigid(button(th, p.settings, settingsIcon).Layout),
-					//layout.Rigid(button(th, p.addContact, addContactIcon).Layout),
+					layout.Rigid(button(th, p.settings, settingsIcon, "Settings").Layout),
+					//layout.Rigid(button(th, p.addContact, addContactIcon, "Add contact").Layout),
 				)
 			}),
 			// Add a caption
@@ -98,19 +94,16 @@ func (p *SpoolPage) Layout(gtx layout.Context) layout.Dimensions {
 
 					// create a click handler for this provider
 					if _, ok := p.providerClicks[providers[i]]; !ok {
-						c := new(gesture.Click)
+						c := new(widget.Clickable)
 						p.providerClicks[providers[i]] = c
 					}
 
 					// attach click handler to this element
-					dims := bg.Layout(gtx, func(gtx C) D {
-						return material.Body2(th, providers[i]).Layout(gtx)
+					return control(gtx, p.providerClicks[providers[i]], "Create a spool on "+providers[i], func(gtx C) D {
+						return bg.Layout(gtx, func(gtx C) D {
+							return material.Body2(th, providers[i]).Layout(gtx)
+						})
 					})
-					a := clip.Rect(image.Rectangle{Max: dims.Size})
-					t := a.Push(gtx.Ops)
-					p.providerClicks[providers[i]].Add(gtx.Ops)
-					t.Pop()
-					return dims
 				})
 			}),
 		)
@@ -131,26 +124,24 @@ func (p *SpoolPage) Event(gtx layout.Context) interface{} {
 		return ShowSettingsClick{}
 	}
 	for provider, click := range p.providerClicks {
-		for _, e := range click.Events(gtx.Queue) {
-			if e.Type == gesture.TypeClick {
-				provider := provider // copy reference to provider
-				go p.once.Do(func() {
-					select{
-					case p.errCh <- p.a.c.CreateRemoteSpoolOn(provider):
-					case <-p.a.c.HaltCh():
-						return
-					}
-				})
-			}
+		if click.Clicked() {
+			provider := provider // copy reference to provider
+			go p.once.Do(func() {
+				select {
+				case p.errCh <- p.a.c.CreateRemoteSpoolOn(provider):
+				case <-p.a.c.HaltCh():
+					return
+				}
+			})
 		}
 	}
 	select {
 	case e := <-p.errCh:
 		if e == nil {
-			notify.Push("Success", "Katzen created a spool")
+			notifications.transient(plainNotice("Success", "Katzen created a spool"))
 			return BackEvent{}
 		} else {
-			notify.Push("Failure", e.Error())
+			notifications.transient(plainNotice("Failure", e.Error()))
 			p.once = new(sync.Once)
 		}
 	default:
@@ -168,7 +159,7 @@ func newSpoolPage(a *App) *SpoolPage {
 	p.once = new(sync.Once)
 	p.errCh = make(chan error)
 	p.submit = &widget.Clickable{}
-	p.providerClicks = make(map[string]*gesture.Click)
+	p.providerClicks = make(map[string]*widget.Clickable)
 	p.a = a
 	return p
 }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/katzenpost/katzenpost/core/crypto/rand"
+	"github.com/katzenpost/katzenpost/core/utils"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// the statefile is written by catshadow as nonce || secretbox(state), keyed by
+// the passphrase stretched with argon2. These functions mirror its format so
+// that the statefile can be re-encrypted without a running client.
+const stateNonceSize = 24
+
+var errStatePassphrase = errors.New("Wrong passphrase")
+
+// errOldStatefileKept is returned when the passphrase was changed but the
+// statefile encrypted with the old passphrase could not be shredded
+var errOldStatefileKept = errors.New("the old statefile could not be removed")
+
+// stateKey derives the statefile key from a passphrase, as catshadow does
+func stateKey(passphrase []byte) *[32]byte {
+	var key [32]byte
+	k := argon2.Key(passphrase, nil, 3, 32*1024, 4, 32)
+	copy(key[:], k)
+	utils.ExplicitBzero(k)
+	return &key
+}
+
+// decryptStatefile returns the plaintext state of the statefile
+func decryptStatefile(statefile string, passphrase []byte) ([]byte, error) {
+	raw, err := os.ReadFile(statefile)
+	if err != nil {
+		return nil, err
+	}
+	return decryptState(raw, passphrase)
+}
+
+func decryptState(raw, passphrase []byte) ([]byte, error) {
+	if len(raw) < stateNonceSize+secretbox.Overhead {
+		return nil, errStatePassphrase
+	}
+	var nonce [stateNonceSize]byte
+	copy(nonce[:], raw[:stateNonceSize])
+	key := stateKey(passphrase)
+	defer utils.ExplicitBzero(key[:])
+	state, ok := secretbox.Open(nil, raw[stateNonceSize:], &nonce, key)
+	if !ok {
+		return nil, errStatePassphrase
+	}
+	return state, nil
+}
+
+func encryptState(state, passphrase []byte) ([]byte, error) {
+	var nonce [stateNonceSize]byte
+	if _, err := rand.Reader.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key := stateKey(passphrase)
+	defer utils.ExplicitBzero(key[:])
+	return secretbox.Seal(nonce[:], state, &nonce, key), nil
+}
+
+// writeFileSync writes b to path and flushes it to disk
+func writeFileSync(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes the renames in the directory of path to disk
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// rekeyStatefile re-encrypts the statefile with a new passphrase. The new
+// statefile is written beside the old one and read back before it replaces
+// it. The old statefile is then shredded, along with the backup file
+// catshadow keeps, so that no copy encrypted with the old passphrase remains.
+// The client must be shut down first.
+func rekeyStatefile(statefile string, oldPassphrase, newPassphrase []byte) error {
+	state, err := decryptStatefile(statefile, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	defer utils.ExplicitBzero(state)
+	raw, err := encryptState(state, newPassphrase)
+	if err != nil {
+		return err
+	}
+
+	tmp := statefile + ".tmp"
+	if err := writeFileSync(tmp, raw); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	written, err := decryptStatefile(tmp, newPassphrase)
+	if err == nil && !bytes.Equal(written, state) {
+		err = errors.New("The new statefile could not be verified")
+	}
+	utils.ExplicitBzero(written)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(statefile, statefile+"~"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, statefile); err != nil {
+		// put the old statefile back
+		os.Rename(statefile+"~", statefile)
+		return err
+	}
+	if err := syncDir(statefile); err != nil {
+		return err
+	}
+	if err := shredFile(statefile + "~"); err != nil {
+		return fmt.Errorf("%w from %s: %s", errOldStatefileKept, statefile+"~", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"gioui.org/app"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+const (
+	themeDark     = "dark"
+	themeLight    = "light"
+	themeContrast = "contrast"
+	themeCustom   = "custom"
+)
+
+// theme holds the colors of the user interface. ContrastBg and ContrastFg
+// are the button colors; ContrastFg is also used for icons drawn on Bg.
+type theme struct {
+	Bg, Fg, ContrastBg, ContrastFg color.NRGBA
+	// Sent and Received are the colors of the message bubbles
+	Sent, Received color.NRGBA
+}
+
+// themeColor is a color of a theme that can be changed in the custom palette
+type themeColor struct {
+	name  string
+	color *color.NRGBA
+}
+
+var (
+	builtinThemes = map[string]theme{
+		themeDark: {
+			Bg:         rgb(0x000000),
+			Fg:         rgb(0xffffff),
+			ContrastBg: rgb(0x222222),
+			ContrastFg: rgb(0x777777),
+			Sent:       rgb(0x222222),
+			Received:   rgb(0x777777),
+		},
+		themeLight: {
+			Bg:         rgb(0xffffff),
+			Fg:         rgb(0x000000),
+			ContrastBg: rgb(0xdddddd),
+			ContrastFg: rgb(0x333333),
+			Sent:       rgb(0xcfe3ff),
+			Received:   rgb(0xe6e6e6),
+		},
+		themeContrast: {
+			Bg:         rgb(0x000000),
+			Fg:         rgb(0xffffff),
+			ContrastBg: rgb(0x0000aa),
+			ContrastFg: rgb(0xffff00),
+			Sent:       rgb(0x0000aa),
+			Received:   rgb(0x404040),
+		},
+	}
+
+	// activeTheme is the theme applied to th
+	activeTheme = builtinThemes[themeDark]
+)
+
+// colors returns the colors of the theme in the order they are edited
+func (t *theme) colors() []themeColor {
+	return []themeColor{
+		{"Background", &t.Bg},
+		{"Text", &t.Fg},
+		{"Buttons", &t.ContrastBg},
+		{"Button Text and Icons", &t.ContrastFg},
+		{"Sent Messages", &t.Sent},
+		{"Received Messages", &t.Received},
+	}
+}
+
+// palette returns the material palette of the theme
+func (t theme) palette() material.Palette {
+	return material.Palette{Bg: t.Bg, Fg: t.Fg, ContrastBg: t.ContrastBg, ContrastFg: t.ContrastFg}
+}
+
+// themeName returns the name of the theme chosen in the settings
+func themeName(c *catshadow.Client) string {
+	if b, err := c.GetBlob("Theme"); err == nil {
+		name := string(b)
+		if _, ok := builtinThemes[name]; ok || name == themeCustom {
+			return name
+		}
+	}
+	return themeDark
+}
+
+// customTheme returns the user defined palette, which starts as a copy of the dark theme
+func customTheme(c *catshadow.Client) theme {
+	t := builtinThemes[themeDark]
+	if b, err := c.GetBlob("CustomTheme"); err == nil {
+		cbor.Unmarshal(b, &t)
+	}
+	return t
+}
+
+// setCustomTheme saves the user defined palette
+func setCustomTheme(c *catshadow.Client, t theme) error {
+	b, err := cbor.Marshal(t)
+	if err != nil {
+		return err
+	}
+	c.AddBlob("CustomTheme", b)
+	return nil
+}
+
+// loadTheme returns the theme chosen in the settings
+func loadTheme(c *catshadow.Client) theme {
+	name := themeName(c)
+	if name == themeCustom {
+		return customTheme(c)
+	}
+	return builtinThemes[name]
+}
+
+// applyTheme makes t the active theme; pages read their colors from th as
+// they are laid out, so the change shows on the next frame
+func (a *App) applyTheme(t theme) {
+	activeTheme = t
+	th.Palette = t.palette()
+	a.w.Option(app.NavigationColor(t.Bg), app.StatusColor(t.Bg))
+	a.w.Invalidate()
+}
+
+// parseColor parses a color written as #rrggbb
+func parseColor(s string) (color.NRGBA, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return color.NRGBA{}, false
+	}
+	c, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return rgb(uint32(c)), true
+}
+
+// formatColor writes a color as #rrggbb
+func formatColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// themeEditor is the theme chooser and custom palette editor of the settings page
+type themeEditor struct {
+	a      *App
+	choice *widget.Enum
+	colors []*widget.Editor
+}
+
+func newThemeEditor(a *App) *themeEditor {
+	e := &themeEditor{a: a, choice: &widget.Enum{Value: themeName(a.c)}}
+	custom := customTheme(a.c)
+	for _, tc := range custom.colors() {
+		ed := &widget.Editor{SingleLine: true}
+		ed.SetText(formatColor(*tc.color))
+		e.colors = append(e.colors, ed)
+	}
+	return e
+}
+
+// rows returns the theme chooser, followed by the palette editor when the custom theme is chosen
+func (e *themeEditor) rows() []layout.Widget {
+	rows := []layout.Widget{func(gtx C) D {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+				return inset.Layout(gtx, material.Body1(th, "Theme").Layout)
+			}),
+			layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(material.RadioButton(th, e.choice, themeDark, "Dark").Layout),
+					layout.Rigid(material.RadioButton(th, e.choice, themeLight, "Light").Layout),
+					layout.Rigid(material.RadioButton(th, e.choice, themeContrast, "High Contrast").Layout),
+					layout.Rigid(material.RadioButton(th, e.choice, themeCustom, "Custom").Layout),
+				)
+			}),
+		)
+	}}
+	if e.choice.Value != themeCustom {
+		return rows
+	}
+	for i, tc := range activeTheme.colors() {
+		i, tc := i, tc
+		rows = append(rows, func(gtx C) D {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(settingNameColumnWidth, func(gtx C) D {
+					return inset.Layout(gtx, material.Body2(th, tc.name).Layout)
+				}),
+				layout.Flexed(settingDetailsColumnWidth, func(gtx C) D {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(func(gtx C) D {
+							return inset.Layout(gtx, func(gtx C) D {
+								sz := gtx.Dp(unit.Dp(20))
+								gtx.Constraints.Min.X, gtx.Constraints.Min.Y = sz, sz
+								bg := Background{Color: *tc.color, Radius: unit.Dp(4)}
+								return bg.Layout(gtx, func(gtx C) D {
+									return D{Size: gtx.Constraints.Min}
+								})
+							})
+						}),
+						layout.Flexed(1, func(gtx C) D {
+							return inset.Layout(gtx, material.Editor(th, e.colors[i], "#rrggbb").Layout)
+						}),
+					)
+				}),
+			)
+		})
+	}
+	return rows
+}
+
+// event saves and applies the chosen theme and the edited palette colors
+func (e *themeEditor) event() {
+	c := e.a.c
+	if e.choice.Changed() {
+		c.AddBlob("Theme", []byte(e.choice.Value))
+		e.a.applyTheme(loadTheme(c))
+	}
+	for i, ed := range e.colors {
+		for _, ev := range ed.Events() {
+			if _, ok := ev.(widget.ChangeEvent); !ok {
+				continue
+			}
+			col, ok := parseColor(ed.Text())
+			if !ok {
+				continue
+			}
+			custom := customTheme(c)
+			*custom.colors()[i].color = col
+			setCustomTheme(c, custom)
+			if e.choice.Value == themeCustom {
+				e.a.applyTheme(custom)
+			}
+		}
+	}
+}
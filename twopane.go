@@ -0,0 +1,126 @@
+package main
+
+import (
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+const (
+	// twoPaneBreakpoint is the window width from which the conversation is
+	// shown beside the contact list
+	twoPaneBreakpoint = unit.Dp(720)
+	contactPaneWidth  = unit.Dp(360)
+)
+
+// Layout returns the contact list, and the open conversation beside it when
+// the window is wide enough
+func (p *HomePage) Layout(gtx layout.Context) layout.Dimensions {
+	p.wide = gtx.Constraints.Max.X >= gtx.Dp(twoPaneBreakpoint)
+	if !p.wide {
+		return p.layoutContacts(gtx)
+	}
+	return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			gtx.Constraints.Min.X = gtx.Dp(contactPaneWidth)
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			return p.layoutContacts(gtx)
+		}),
+		// divider
+		layout.Rigid(func(gtx C) D {
+			gtx.Constraints.Min.X = gtx.Dp(unit.Dp(1))
+			gtx.Constraints.Min.Y = gtx.Constraints.Max.Y
+			return fill{th.ContrastBg}.Layout(gtx)
+		}),
+		layout.Flexed(1, func(gtx C) D {
+			if p.conversation != nil {
+				return p.conversation.Layout(gtx)
+			}
+			bg := Background{
+				Color: th.Bg,
+				Inset: layout.Inset{},
+			}
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return bg.Layout(gtx, func(gtx C) D {
+				return layout.Center.Layout(gtx, material.Body2(th, "Choose a conversation").Layout)
+			})
+		}),
+	)
+}
+
+// Event handles the open conversation, then the contact list
+func (p *HomePage) Event(gtx layout.Context) interface{} {
+	if p.conversation != nil {
+		if !p.wide {
+			// the window became too narrow, so the conversation is pushed as a page
+			nickname := p.conversation.nickname
+			p.closeConversation()
+			return ChooseContactClick{nickname: nickname}
+		}
+		if e := p.conversation.Event(gtx); e != nil {
+			if _, ok := e.(BackEvent); ok {
+				p.closeConversation()
+				return RedrawEvent{}
+			}
+			return e
+		}
+	}
+	return p.contactsEvent(gtx)
+}
+
+// keyScopes returns the shortcuts of the contact list, and those of the open conversation
+func (p *HomePage) keyScopes() []string {
+	if p.conversation != nil {
+		return []string{scopeHome, scopeConversation}
+	}
+	return []string{scopeHome}
+}
+
+// isOpen returns true if the conversation with nickname is shown beside the contact list
+func (p *HomePage) isOpen(nickname string) bool {
+	return p.conversation != nil && p.conversation.nickname == nickname
+}
+
+// openConversation replaces the conversation shown beside the contact list
+func (p *HomePage) openConversation(nickname string) {
+	if p.isOpen(nickname) {
+		return
+	}
+	p.closeConversation()
+	p.conversation = newConversationPage(p.a, nickname)
+	p.convStop = make(chan struct{})
+	// the conversation stops when it is closed, or when the home page is
+	// stopped by being covered, popped or cleared on lock
+	convStop, pageStop, stop := p.convStop, p.stop, make(chan struct{})
+	go func() {
+		select {
+		case <-convStop:
+		case <-pageStop:
+		}
+		close(stop)
+	}()
+	p.conversation.Start(stop)
+}
+
+// closeConversation stops the conversation shown beside the contact list,
+// whose messages have been read
+func (p *HomePage) closeConversation() {
+	if p.conversation != nil {
+		if p.a.c != nil {
+			markRead(p.a.c, p.conversation.nickname)
+		}
+		close(p.convStop)
+		p.conversation = nil
+		p.convStop = nil
+	}
+}
+
+// openConversation shows the conversation with nickname in the right pane of
+// a wide home page, and otherwise pushes it onto the page stack
+func (a *App) openConversation(nickname string) {
+	if home, ok := a.stack.Current().(*HomePage); ok && home.wide {
+		home.openConversation(nickname)
+		return
+	}
+	a.stack.Push(newConversationPage(a, nickname))
+}
@@ -1,11 +1,140 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
 	"gioui.org/layout"
 	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/katzenpost/katzenpost/catshadow"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	// freeUnlockAttempts is the number of failed unlocks allowed before backing off
+	freeUnlockAttempts = 3
+	// unlockBackoff is the delay after the first failure beyond the free attempts,
+	// doubling with each further failure up to maxUnlockBackoff
+	unlockBackoff    = 5 * time.Second
+	maxUnlockBackoff = 30 * time.Minute
+	// maxWipeAfter is the largest number of failed unlocks that can be chosen before wiping
+	maxWipeAfter = 20.0
+)
+
+var (
+	errCorruptStatefile = errors.New("The statefile is damaged")
+	errConfig           = errors.New("Configuration error")
 )
 
+// validStatefileSize returns false if the file at path is too short to be a
+// statefile, which catshadow cannot decrypt without panicking
+func validStatefileSize(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Size() >= stateNonceSize+secretbox.Overhead
+}
+
+// statefileError tells a wrong passphrase apart from a statefile that cannot be read
+func statefileError(err error) error {
+	switch {
+	case err == catshadow.DecryptStateFailed:
+		return errStatePassphrase
+	case errors.Is(err, errCorruptStatefile):
+		return err
+	case os.IsNotExist(err), os.IsPermission(err):
+		return err
+	}
+	return fmt.Errorf("%w: %v", errCorruptStatefile, err)
+}
+
+// unlockAttempts counts the failed unlocks of a statefile. It is kept beside
+// the statefile, unencrypted, so that it can be read before unlocking. It
+// slows down guessing through the sign in page; it cannot stop anyone who
+// can copy the statefile.
+type unlockAttempts struct {
+	Failures int
+	Last     time.Time
+	// WipeAfter destroys the statefile after this many failures, if not 0
+	WipeAfter int
+}
+
+// attemptsPath returns the path of the attempt counter of the current profile
+func attemptsPath() (string, error) {
+	statefile, err := statefilePath()
+	if err != nil {
+		return "", err
+	}
+	return statefile + ".attempts", nil
+}
+
+// loadAttempts returns the attempt counter of the current profile
+func loadAttempts() *unlockAttempts {
+	u := &unlockAttempts{}
+	if path, err := attemptsPath(); err == nil {
+		if b, err := os.ReadFile(path); err == nil {
+			cbor.Unmarshal(b, u)
+		}
+	}
+	return u
+}
+
+// save writes the attempt counter of the current profile
+func (u *unlockAttempts) save() error {
+	path, err := attemptsPath()
+	if err != nil {
+		return err
+	}
+	b, err := cbor.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return writeFileSync(path, b)
+}
+
+// wait returns how long to wait before the next attempt is allowed
+func (u *unlockAttempts) wait() time.Duration {
+	if u.Failures < freeUnlockAttempts {
+		return 0
+	}
+	backoff := maxUnlockBackoff
+	if n := u.Failures - freeUnlockAttempts; n < 16 {
+		if d := unlockBackoff << n; d < maxUnlockBackoff {
+			backoff = d
+		}
+	}
+	if wait := time.Until(u.Last.Add(backoff)); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// recordUnlockFailure counts a wrong passphrase, destroying the statefile of
+// the current profile if the failure limit was reached
+func recordUnlockFailure() {
+	u := loadAttempts()
+	u.Failures++
+	u.Last = time.Now()
+	if u.WipeAfter > 0 && u.Failures >= u.WipeAfter {
+		if statefile, err := statefilePath(); err == nil {
+			destroyStatefile(statefile)
+			destroyStatefile(duressSlot(statefile))
+		}
+		u.Failures = 0
+	}
+	u.save()
+}
+
+// recordUnlockSuccess resets the failure count of the current profile
+func recordUnlockSuccess() {
+	u := loadAttempts()
+	if u.Failures > 0 {
+		u.Failures = 0
+		u.save()
+	}
+}
+
 type unlockPage struct {
 	result chan interface{}
 }
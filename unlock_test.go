@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnlockAttemptsWait(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{freeUnlockAttempts - 1, 0},
+		{freeUnlockAttempts, unlockBackoff},
+		{freeUnlockAttempts + 1, 2 * unlockBackoff},
+		{freeUnlockAttempts + 3, 8 * unlockBackoff},
+		{freeUnlockAttempts + 10, maxUnlockBackoff},
+		// the shift would overflow
+		{freeUnlockAttempts + 100, maxUnlockBackoff},
+	}
+	for _, test := range tests {
+		u := &unlockAttempts{Failures: test.failures, Last: time.Now()}
+		got := u.wait()
+		// time passes between setting Last and calling wait
+		if got > test.want || got < test.want-time.Second {
+			t.Errorf("%d failures: waiting %v, want %v", test.failures, got, test.want)
+		}
+	}
+
+	u := &unlockAttempts{Failures: freeUnlockAttempts + 1, Last: time.Now().Add(-time.Minute)}
+	if got := u.wait(); got != 0 {
+		t.Errorf("waiting %v after the backoff passed", got)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+	"github.com/katzenpost/katzenpost/core/utils"
+	memspoolclient "github.com/katzenpost/katzenpost/memspool/client"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+var (
+	verifiedIcon, _ = widget.NewIcon(icons.ActionVerifiedUser)
+	safetyLabel     = []byte("katzen safety number")
+	errNoSpool      = errors.New("no spool has been created")
+)
+
+// contactFingerprint returns a digest of the two spools that carry the
+// conversation with a contact: ours, which the contact writes to, and theirs,
+// which we write to.
+//
+// The spools stand in for identity keys. catshadow's double ratchet keeps no
+// long-term identity key: its key exchange values are erased once the
+// exchange completes, and the root and chain keys that remain change with
+// every message and differ between the two sides. The spool descriptors are
+// exchanged inside the same key exchange and never change afterwards, so both
+// parties compute the same digest at any time, and a man in the middle would
+// have to substitute spools of his own.
+func contactFingerprint(c *catshadow.Client, contact *catshadow.Contact) ([]byte, error) {
+	if contact.IsPending {
+		return nil, catshadow.ErrPendingKeyExchange
+	}
+	ours := c.SpoolWriteDescriptor()
+	if ours == nil {
+		return nil, errNoSpool
+	}
+	b, err := contact.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	// the serialized contact holds the ratchet keys too
+	defer utils.ExplicitBzero(b)
+	s := struct {
+		SpoolWriteDescriptor *memspoolclient.SpoolWriteDescriptor
+	}{}
+	if err := cbor.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	theirs := s.SpoolWriteDescriptor
+	if theirs == nil {
+		return nil, catshadow.ErrPendingKeyExchange
+	}
+	return spoolsFingerprint(ours, theirs), nil
+}
+
+// spoolsFingerprint returns the digest of two spools, which is the same
+// whichever side of the conversation computes it
+func spoolsFingerprint(ours, theirs *memspoolclient.SpoolWriteDescriptor) []byte {
+	// hash the spools in the same order on both sides
+	spools := [][]byte{spoolIdentity(ours), spoolIdentity(theirs)}
+	if bytes.Compare(spools[0], spools[1]) > 0 {
+		spools[0], spools[1] = spools[1], spools[0]
+	}
+	h := sha512.New()
+	h.Write(safetyLabel)
+	for _, spool := range spools {
+		h.Write(spool)
+	}
+	return h.Sum(nil)
+}
+
+// spoolIdentity returns the length-prefixed provider and ID of a spool
+func spoolIdentity(d *memspoolclient.SpoolWriteDescriptor) []byte {
+	b := make([]byte, 0, 2+len(d.Provider)+len(d.ID))
+	b = append(b, byte(len(d.Provider)>>8), byte(len(d.Provider)))
+	b = append(b, d.Provider...)
+	return append(b, d.ID[:]...)
+}
+
+// safetyNumber formats a fingerprint as 12 groups of 5 digits
+func safetyNumber(fp []byte) string {
+	groups := make([]string, 0, 12)
+	var b [8]byte
+	for i := 0; i+5 <= len(fp) && len(groups) < 12; i += 5 {
+		copy(b[3:], fp[i:i+5])
+		groups = append(groups, fmt.Sprintf("%05d", binary.BigEndian.Uint64(b[:])%100000))
+	}
+	return strings.Join(groups, " ")
+}
+
+// isVerified returns true if the contact was marked as verified
+func isVerified(c *catshadow.Client, nickname string) bool {
+	_, err := c.GetBlob("verified://" + nickname)
+	return err == nil
+}
+
+// VerifyContactPage shows the safety number of a contact
+type VerifyContactPage struct {
+	a        *App
+	nickname string
+	back     *widget.Clickable
+	verified *widget.Bool
+	number   string
+}
+
+// VerifyContact is the event that requests the verification page of a contact
+type VerifyContact struct {
+	nickname string
+}
+
+// Layout returns the safety number and fractal of the contact
+func (p *VerifyContactPage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Verify "+p.nickname).Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Flexed(1, func(gtx C) D {
+				if p.number == "" {
+					return layout.Dimensions{}
+				}
+				co := Contactal{SharedSecret: p.number}
+				return co.Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					if p.number == "" {
+						return material.Body2(th, "No safety number is available until the key exchange with this contact completes.").Layout(gtx)
+					}
+					l := material.Body1(th, p.number)
+					l.Font.Variant = "Mono"
+					return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(l.Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Caption(th, "Compare the number and image with "+p.nickname+" in person or over a call you trust.").Layout),
+					)
+				})
+			}),
+			layout.Rigid(func(gtx C) D {
+				if p.number == "" {
+					return layout.Dimensions{}
+				}
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						return inset.Layout(gtx, material.Body1(th, "Verified").Layout)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return inset.Layout(gtx, material.Switch(th, p.verified, "Verified").Layout)
+					}),
+				)
+			}),
+		)
+	})
+}
+
+// Event handles the verified toggle
+func (p *VerifyContactPage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.verified.Changed() {
+		if p.verified.Value {
+			p.a.c.AddBlob("verified://"+p.nickname, []byte{1})
+		} else {
+			p.a.c.DeleteBlob("verified://" + p.nickname)
+		}
+		return RedrawEvent{}
+	}
+	return nil
+}
+
+func (p *VerifyContactPage) Start(stop <-chan struct{}) {
+}
+
+func newVerifyContactPage(a *App, nickname string) *VerifyContactPage {
+	p := &VerifyContactPage{a: a, nickname: nickname}
+	p.back = &widget.Clickable{}
+	p.verified = &widget.Bool{Value: isVerified(a.c, nickname)}
+	if contact, ok := a.c.GetContacts()[nickname]; ok {
+		if fp, err := contactFingerprint(a.c, contact); err == nil {
+			p.number = safetyNumber(fp)
+		}
+	}
+	return p
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	memspoolclient "github.com/katzenpost/katzenpost/memspool/client"
+)
+
+func TestSafetyNumber(t *testing.T) {
+	alice := &memspoolclient.SpoolWriteDescriptor{ID: [12]byte{1, 2, 3}, Receiver: "spool", Provider: "provider1"}
+	bob := &memspoolclient.SpoolWriteDescriptor{ID: [12]byte{4, 5, 6}, Receiver: "spool", Provider: "provider2"}
+	number := safetyNumber(spoolsFingerprint(alice, bob))
+
+	if !regexp.MustCompile(`^\d{5}( \d{5}){11}$`).MatchString(number) {
+		t.Fatalf("%q is not 12 groups of 5 digits", number)
+	}
+	if got := safetyNumber(spoolsFingerprint(bob, alice)); got != number {
+		t.Errorf("the two sides see %q and %q", number, got)
+	}
+
+	changed := []struct {
+		name         string
+		ours, theirs *memspoolclient.SpoolWriteDescriptor
+	}{
+		{"our spool ID", &memspoolclient.SpoolWriteDescriptor{ID: [12]byte{1, 2, 4}, Provider: "provider1"}, bob},
+		{"our provider", &memspoolclient.SpoolWriteDescriptor{ID: alice.ID, Provider: "provider3"}, bob},
+		{"their spool ID", alice, &memspoolclient.SpoolWriteDescriptor{ID: [12]byte{4, 5, 7}, Provider: "provider2"}},
+		{"their provider", alice, &memspoolclient.SpoolWriteDescriptor{ID: bob.ID, Provider: "provider3"}},
+	}
+	for _, test := range changed {
+		if got := safetyNumber(spoolsFingerprint(test.ours, test.theirs)); got == number {
+			t.Errorf("changing %s kept the safety number %q", test.name, number)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gioui.org/app"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/katzenpost/katzenpost/client/config"
+)
+
+const (
+	// wipeConfirmation must be typed to confirm a panic wipe
+	wipeConfirmation = "WIPE"
+	// wipeChord opens the panic wipe page from the sign in page when enabled with -w
+	wipeChord = "Short-Shift-" + key.NameDeleteForward
+)
+
+// errWiped ends the application after a panic wipe
+var errWiped = errors.New("local data wiped")
+
+// statefileSiblings are the suffixes of the files written beside a statefile
+var statefileSiblings = []string{"", "~", ".tmp", ".replaced", duressSlotSuffix, duressSlotSuffix + "~", duressSlotSuffix + ".tmp", ".attempts"}
+
+// shredFile overwrites a file with random bytes before removing it
+func shredFile(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Mode().IsRegular() {
+		if err := writeRandom(path, int(fi.Size())); err != nil {
+			return err
+		}
+	}
+	return os.Remove(path)
+}
+
+// shredDir shreds every file under dir before removing it
+func shredDir(dir string) error {
+	var errs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			err = shredFile(path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+		return nil
+	})
+	if err := os.RemoveAll(dir); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// logFiles returns the log files named by the client config given with -f
+// and by the configs of the profiles, which may be outside the data directory
+func logFiles(datadir string) []string {
+	var configs []string
+	if len(*clientConfigFile) != 0 {
+		configs = append(configs, *clientConfigFile)
+	}
+	entries, _ := os.ReadDir(filepath.Join(datadir, profilesDirName))
+	for _, e := range entries {
+		if e.IsDir() {
+			configs = append(configs, filepath.Join(datadir, profilesDirName, e.Name(), profileConfigName))
+		}
+	}
+	var files []string
+	for _, path := range configs {
+		// a config that does not load was never used to log
+		cfg, err := config.LoadFile(path)
+		if err == nil && len(cfg.Logging.File) != 0 {
+			files = append(files, cfg.Logging.File)
+		}
+	}
+	return files
+}
+
+// wipeData shreds every file under the data directory, which holds the
+// profiles with their statefiles, configs and logs, and the backups and
+// address books saved to their default location. A statefile given with -s
+// and log files named by the client configs outside the data directory are
+// shredded as well.
+func wipeData() error {
+	var errs []string
+	dir, err := app.DataDir()
+	if err != nil {
+		return err
+	}
+	datadir := filepath.Join(dir, dataDirName)
+	for _, path := range logFiles(datadir) {
+		if err := shredFile(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := shredDir(datadir); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if _, err := os.Stat(*stateFile); err == nil {
+		for _, suffix := range statefileSiblings {
+			if err := shredFile(*stateFile + suffix); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// wipe stops the client and destroys all local data. The clipboard is
+// cleared and the application exits on the next frame.
+func (a *App) wipe() {
+	if a.c != nil {
+		shutdownClient(a.c)
+		a.c = nil
+	}
+	notifications.clear()
+	if err := wipeData(); err != nil {
+		fmt.Fprintf(os.Stderr, "wipe: %v\n", err)
+	}
+	a.wiped = true
+	a.w.Invalidate()
+}
+
+// WipePage confirms a panic wipe
+type WipePage struct {
+	a       *App
+	back    *widget.Clickable
+	wipe    *widget.Clickable
+	confirm *widget.Editor
+}
+
+// ShowWipeClick is the event that requests the panic wipe page
+type ShowWipeClick struct{}
+
+// panicWipe is the event that destroys all local data
+type panicWipe struct{}
+
+// Layout returns the warning and the confirmation field
+func (p *WipePage) Layout(gtx layout.Context) layout.Dimensions {
+	bg := Background{
+		Color: th.Bg,
+		Inset: layout.Inset{},
+	}
+
+	return bg.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Start}.Layout(gtx,
+			// topbar
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(button(th, p.back, backIcon, "Back").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout),
+					layout.Rigid(material.H6(th, "Wipe Everything").Layout),
+					layout.Flexed(1, fill{th.Bg}.Layout))
+			}),
+			layout.Rigid(func(gtx C) D {
+				in := layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}
+				return in.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(th, "Every profile, statefile, duress decoy, backup, address book and log file stored by katzen is overwritten and deleted, the clipboard is cleared and katzen closes. Your contacts and messages cannot be recovered. Backups saved elsewhere are not touched.").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(material.Editor(th, p.confirm, "Type "+wipeConfirmation+" to confirm").Layout),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+						layout.Rigid(func(gtx C) D {
+							if p.confirm.Text() != wipeConfirmation {
+								gtx = gtx.Disabled()
+							}
+							return material.Button(th, p.wipe, "Wipe Everything").Layout(gtx)
+						}),
+					)
+				})
+			}),
+		)
+	})
+}
+
+// Event handles the wipe button, which only acts once the confirmation is typed
+func (p *WipePage) Event(gtx layout.Context) interface{} {
+	if p.back.Clicked() {
+		return BackEvent{}
+	}
+	if p.wipe.Clicked() && p.confirm.Text() == wipeConfirmation {
+		return panicWipe{}
+	}
+	return nil
+}
+
+func (p *WipePage) Start(stop <-chan struct{}) {
+}
+
+func newWipePage(a *App) *WipePage {
+	p := &WipePage{a: a,
+		back:    &widget.Clickable{},
+		wipe:    &widget.Clickable{},
+		confirm: &widget.Editor{SingleLine: true},
+	}
+	p.confirm.Focus()
+	return p
+}
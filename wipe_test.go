@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useDataDir points app.DataDir at a temporary directory for the test and
+// returns the data directory of katzen inside it
+func useDataDir(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("APPDATA", dir)
+	t.Setenv("HOME", dir)
+	datadir, err := appDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(datadir, dir) {
+		t.Skipf("the data directory %s is not under %s on this platform", datadir, dir)
+	}
+	return datadir
+}
+
+// writeFiles creates the files at paths with some contents
+func writeFiles(t *testing.T, paths ...string) {
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWipeData(t *testing.T) {
+	datadir := useDataDir(t)
+	outside := t.TempDir()
+
+	statefile := filepath.Join(datadir, "catshadow_statefile")
+	profile := filepath.Join(datadir, profilesDirName, "work")
+	logFile := filepath.Join(outside, "katzen.log")
+	cfg := strings.Replace(string(cfgWithoutTor), `File = ""`, `File = "`+filepath.ToSlash(logFile)+`"`, 1)
+	writeFiles(t,
+		statefile,
+		statefile+"~",
+		duressSlot(statefile),
+		statefile+".attempts",
+		filepath.Join(profile, "catshadow_statefile"),
+		filepath.Join(datadir, "backup.katzen"),
+		logFile,
+	)
+	if err := os.WriteFile(filepath.Join(profile, profileConfigName), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a statefile given with -s outside the data directory
+	external := filepath.Join(outside, "statefile")
+	writeFiles(t, external, external+"~", duressSlot(external))
+	defer func(s string) { *stateFile = s }(*stateFile)
+	*stateFile = external
+
+	if err := wipeData(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(datadir); !os.IsNotExist(err) {
+		t.Errorf("the data directory still exists: %v", err)
+	}
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Errorf("%s was not wiped", e.Name())
+	}
+}
+
+func TestShredFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statefile")
+	writeFiles(t, path)
+	if err := shredFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists: %v", path, err)
+	}
+	if err := shredFile(path); !os.IsNotExist(err) {
+		t.Errorf("shredding a missing file: %v", err)
+	}
+}
+
+func TestDeleteProfile(t *testing.T) {
+	datadir := useDataDir(t)
+	profile := filepath.Join(datadir, profilesDirName, "work")
+	statefile := filepath.Join(profile, "catshadow_statefile")
+	writeFiles(t, statefile, statefile+"~", duressSlot(statefile), statefile+".attempts")
+
+	if err := deleteProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(profile); !os.IsNotExist(err) {
+		t.Errorf("the profile still exists: %v", err)
+	}
+	if err := deleteProfile(defaultProfile); err == nil {
+		t.Error("the default profile was deleted")
+	}
+}
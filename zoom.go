@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/katzenpost/katzenpost/catshadow"
+)
+
+const (
+	defaultTextSize = 16
+	minTextSize     = 10.0
+	maxTextSize     = 32.0
+	// the interface scale and page zoom are percentages
+	defaultUIScale = 100
+	minUIScale     = 50.0
+	maxUIScale     = 300.0
+	zoomStep       = 10
+	minZoom        = 50
+	maxZoom        = 300
+)
+
+// displayScale holds the text size, the interface scale and the zoom of
+// each page, which are kept after locking so the sign in page looks the same
+type displayScale struct {
+	textSize int
+	uiScale  int
+	// zoom is the zoom of each page, by page type
+	zoom map[string]int
+}
+
+var display = &displayScale{textSize: defaultTextSize, uiScale: defaultUIScale, zoom: make(map[string]int)}
+
+// intBlob returns the integer setting stored in the blob name, or def if it is not set
+func intBlob(c *catshadow.Client, name string, def int) int {
+	if b, err := c.GetBlob(name); err == nil {
+		if i, err := strconv.Atoi(string(b)); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+// loadDisplay reads the display settings of the unlocked client
+func loadDisplay(c *catshadow.Client) {
+	display.textSize = intBlob(c, "TextSize", defaultTextSize)
+	display.uiScale = intBlob(c, "UIScale", defaultUIScale)
+	display.zoom = make(map[string]int)
+	if b, err := c.GetBlob("PageZoom"); err == nil {
+		cbor.Unmarshal(b, &display.zoom)
+	}
+	th.TextSize = unit.Sp(display.textSize)
+}
+
+// pageName identifies a page for its zoom level
+func pageName(p Page) string {
+	return fmt.Sprintf("%T", p)
+}
+
+// zoomOf returns the zoom of a page, in percent
+func (d *displayScale) zoomOf(p Page) int {
+	if z, ok := d.zoom[pageName(p)]; ok {
+		return z
+	}
+	return 100
+}
+
+// scale returns gtx with its metric scaled by the interface scale and the zoom of p
+func (d *displayScale) scale(gtx layout.Context, p Page) layout.Context {
+	s := float32(d.uiScale*d.zoomOf(p)) / (100 * 100)
+	gtx.Metric.PxPerDp *= s
+	gtx.Metric.PxPerSp *= s
+	return gtx
+}
+
+// zoom changes the zoom of the current page by delta percent, or resets it if delta is 0
+func (a *App) zoom(delta int) {
+	page := a.stack.Current()
+	z := display.zoomOf(page) + delta
+	switch {
+	case delta == 0:
+		z = 100
+	case z < minZoom:
+		z = minZoom
+	case z > maxZoom:
+		z = maxZoom
+	}
+	if z == 100 {
+		delete(display.zoom, pageName(page))
+	} else {
+		display.zoom[pageName(page)] = z
+	}
+	if a.c != nil {
+		if b, err := cbor.Marshal(display.zoom); err == nil {
+			a.c.AddBlob("PageZoom", b)
+		}
+	}
+	a.w.Invalidate()
+}
+
+// setTextSize changes the size of body text, which the other text styles are relative to
+func (a *App) setTextSize(sp int) {
+	display.textSize = sp
+	th.TextSize = unit.Sp(sp)
+}
+
+// saveTextSize stores the text size set by setTextSize
+func (a *App) saveTextSize() {
+	a.c.AddBlob("TextSize", []byte(strconv.Itoa(display.textSize)))
+}
+
+// setUIScale changes the scale of the whole interface, in percent
+func (a *App) setUIScale(percent int) {
+	display.uiScale = percent
+}
+
+// saveUIScale stores the interface scale set by setUIScale
+func (a *App) saveUIScale() {
+	a.c.AddBlob("UIScale", []byte(strconv.Itoa(display.uiScale)))
+}